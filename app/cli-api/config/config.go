@@ -8,16 +8,69 @@ import (
 )
 
 type ServerConfig struct {
-	Port         int           `json:"port"`
-	Host         string        `json:"host"`
-	ReadTimeout  Duration      `json:"read_timeout"`
-	WriteTimeout Duration      `json:"write_timeout"`
-	IdleTimeout  Duration      `json:"idle_timeout"`
+	Port            int       `json:"port"`
+	Host            string    `json:"host"`
+	ReadTimeout     Duration  `json:"read_timeout"`
+	WriteTimeout    Duration  `json:"write_timeout"`
+	IdleTimeout     Duration  `json:"idle_timeout"`
+	ShutdownTimeout Duration  `json:"shutdown_timeout"`
+	TLS             TLSConfig `json:"tls"`
+
+	// User/Group, if set, are the unprivileged account the process drops to
+	// via setuid/setgid on Linux immediately after binding the listener, so
+	// the server can bind a privileged port (e.g. 443) without running as
+	// root thereafter. No-op on other platforms.
+	User  string `json:"user,omitempty"`
+	Group string `json:"group,omitempty"`
+}
+
+// TLSConfig configures optional TLS/mTLS termination for the API server.
+// When CertFile/KeyFile are set, the server serves TLS (ServeTLS on its bound
+// listener) instead of plain HTTP.
+type TLSConfig struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+
+	// ClientCAFile, if set, enables mTLS: client certs are verified against
+	// this CA and authMiddleware accepts a verified cert's CommonName in
+	// place of an X-API-Key.
+	ClientCAFile      string `json:"client_ca_file,omitempty"`
+	RequireClientCert bool   `json:"require_client_cert,omitempty"`
+
+	// MinVersion is "1.2" or "1.3"; defaults to "1.2".
+	MinVersion string `json:"min_version,omitempty"`
+}
+
+// Enabled reports whether TLS termination is configured.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
 }
 
 type AuthConfig struct {
 	APIKeys     []string `json:"api_keys"`
 	RequireAuth bool     `json:"require_auth"`
+
+	// JWT bearer token support, accepted alongside X-API-Key. JWTSecret
+	// verifies HS256 tokens; JWKSURL verifies RS256 tokens against a
+	// rotating key set, fetched by "kid".
+	JWTSecret   string   `json:"jwt_secret,omitempty"`
+	JWTIssuer   string   `json:"jwt_issuer,omitempty"`
+	JWTAudience string   `json:"jwt_audience,omitempty"`
+	JWKSURL     string   `json:"jwks_url,omitempty"`
+	TokenTTL    Duration `json:"token_ttl,omitempty"`
+
+	// Scopes maps a scope name (e.g. "jobs:read") to the routes it
+	// authorizes. A JWT's `scope` claim (space-separated) must cover the
+	// requested route via at least one of these rules; when Scopes is
+	// empty, no scope check is made.
+	Scopes map[string][]ScopeRule `json:"scopes,omitempty"`
+}
+
+// ScopeRule authorizes a method + route prefix pair. Method "*" matches any
+// method.
+type ScopeRule struct {
+	Method string `json:"method"`
+	Prefix string `json:"prefix"`
 }
 
 type CLIConfig struct {
@@ -36,11 +89,87 @@ type WebhookConfig struct {
 }
 
 type JobsConfig struct {
-	MaxConcurrent   int           `json:"max_concurrent"`
+	MaxConcurrent int `json:"max_concurrent"`
+
+	// MaxConcurrentPerTenant, if set, caps how many jobs sharing a
+	// JobRequest.Tenant may run at once, independent of (and never looser
+	// than) MaxConcurrent - it bounds one tenant's share of the global cap
+	// so a burst from one tenant can't starve out the others. Zero means no
+	// per-tenant cap.
+	MaxConcurrentPerTenant int `json:"max_concurrent_per_tenant,omitempty"`
+
 	CleanupAfter    time.Duration `json:"cleanup_after"`
 	DefaultTTL      time.Duration `json:"default_ttl"`
 	CleanupInterval time.Duration `json:"cleanup_interval"`
 	MaxHistorySize  int           `json:"max_history_size"`
+	LogBufferLines  int           `json:"log_buffer_lines"`
+
+	// DebounceWindow is how long CreateJob waits after the last request for a
+	// given (command, args, plan_id) key before actually starting it,
+	// coalescing a burst into one execution. Zero disables debouncing, except
+	// for commands named in DebounceAlwaysCommands.
+	DebounceWindow time.Duration `json:"debounce_window"`
+
+	// DebounceExcludeCommands lists commands that are never debounced/
+	// coalesced, even when DebounceWindow > 0 - e.g. "chat", where every
+	// request is a distinct user turn that must run on its own.
+	DebounceExcludeCommands []string `json:"debounce_exclude_commands,omitempty"`
+
+	// DebounceAlwaysCommands lists commands that are always debounced/
+	// coalesced, even when DebounceWindow is 0 - e.g. "build", where
+	// back-to-back requests against the same plan almost always mean "the
+	// same build, fired twice". Falls back to a 2s window for these commands
+	// when DebounceWindow itself is unset.
+	DebounceAlwaysCommands []string `json:"debounce_always_commands,omitempty"`
+
+	// StorePath, if set, persists jobs to a BoltDB file at this path so
+	// they survive a restart. Left empty, jobs live only in memory (the
+	// same store tests use).
+	StorePath string `json:"store_path,omitempty"`
+
+	// InterruptedThreshold is how old a still-pending/running job found in
+	// the store at startup has to be before it's marked failed with
+	// "interrupted by restart" instead of assumed to belong to a
+	// still-in-flight rehydration. Defaults to 0, meaning every
+	// pending/running job found at startup is treated as interrupted,
+	// since this process's in-memory executor state for it is gone either
+	// way.
+	InterruptedThreshold time.Duration `json:"interrupted_threshold,omitempty"`
+
+	// RequireActions, if true, rejects a CreateJob request that specifies a
+	// raw Command instead of a registered Action (see Config.Actions) -
+	// flip this on once every client has migrated off the raw CLI-command
+	// vocabulary. Defaults to false, so legacy raw-command requests remain
+	// supported.
+	RequireActions bool `json:"require_actions,omitempty"`
+}
+
+// ActionConfig declares a single named action (see jobs.ActionRegistry): a
+// stable, typed shorthand for a CLI command and its argument order, so a
+// client can invoke e.g. "summarize-plan" instead of knowing Plandex's exact
+// CLI vocabulary and argument order.
+type ActionConfig struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Command     string `json:"command"`
+
+	// ArgTemplate is the action's argv, in order. Each entry is either a
+	// literal string passed through as-is, or a "{name:type,...}"
+	// placeholder bound from the request's Params, e.g. "{prompt:string,
+	// required}" or "{branch:string,default=main}".
+	ArgTemplate []string `json:"arg_template,omitempty"`
+
+	// Timeout, if set, bounds a single attempt of a job created via this
+	// action.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// MaxConcurrent, if set, caps how many jobs created via this action may
+	// run at once, independent of (and in addition to) Jobs.MaxConcurrent.
+	MaxConcurrent int `json:"max_concurrent,omitempty"`
+
+	// WebhookURL is the default webhook for jobs created via this action
+	// when the request doesn't specify its own.
+	WebhookURL string `json:"webhook_url,omitempty"`
 }
 
 type SecurityConfig struct {
@@ -66,12 +195,18 @@ func (d *Duration) UnmarshalJSON(b []byte) error {
 }
 
 type Config struct {
-	Server   ServerConfig    `json:"server"`
-	Auth     AuthConfig      `json:"auth"`
-	CLI      CLIConfig       `json:"cli"`
-	Webhooks WebhookConfig   `json:"webhooks"`
-	Jobs     JobsConfig      `json:"jobs"`
-	Security SecurityConfig  `json:"security"`
+	Server   ServerConfig   `json:"server"`
+	Auth     AuthConfig     `json:"auth"`
+	CLI      CLIConfig      `json:"cli"`
+	Webhooks WebhookConfig  `json:"webhooks"`
+	Jobs     JobsConfig     `json:"jobs"`
+	Security SecurityConfig `json:"security"`
+
+	// Actions declares the named action templates jobs.ActionRegistry
+	// resolves CreateJob's Action field through. Empty means no actions are
+	// registered, and (unless Jobs.RequireActions is set) every request must
+	// use a raw Command instead.
+	Actions []ActionConfig `json:"actions,omitempty"`
 }
 
 func LoadConfig(path string) (*Config, error) {
@@ -103,6 +238,15 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Server.IdleTimeout.Duration == 0 {
 		cfg.Server.IdleTimeout.Duration = 60 * time.Second
 	}
+	if cfg.Server.ShutdownTimeout.Duration == 0 {
+		cfg.Server.ShutdownTimeout.Duration = 30 * time.Second
+	}
+	if cfg.Server.TLS.MinVersion == "" {
+		cfg.Server.TLS.MinVersion = "1.2"
+	}
+	if cfg.Auth.TokenTTL.Duration == 0 {
+		cfg.Auth.TokenTTL.Duration = 15 * time.Minute
+	}
 	if cfg.Jobs.MaxConcurrent == 0 {
 		cfg.Jobs.MaxConcurrent = 5
 	}
@@ -118,6 +262,9 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Jobs.MaxHistorySize == 0 {
 		cfg.Jobs.MaxHistorySize = 1000
 	}
+	if cfg.Jobs.LogBufferLines == 0 {
+		cfg.Jobs.LogBufferLines = 1000
+	}
 	if cfg.Webhooks.MaxRetries == 0 {
 		cfg.Webhooks.MaxRetries = 3
 	}
@@ -138,6 +285,9 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid port: %d", c.Server.Port)
 	}
+	if c.Server.TLS.Enabled() && c.Server.TLS.MinVersion != "1.2" && c.Server.TLS.MinVersion != "1.3" {
+		return fmt.Errorf("invalid tls.min_version: %s (must be \"1.2\" or \"1.3\")", c.Server.TLS.MinVersion)
+	}
 	return nil
 }
 
@@ -147,11 +297,11 @@ func findSTLDirectory() string {
 	if err != nil {
 		return "."
 	}
-	
+
 	stlPath := homeDir + "/STL"
 	if _, err := os.Stat(stlPath + "/.plandex-v2"); err == nil {
 		return stlPath
 	}
-	
+
 	return "."
 }
@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"plandex-cli/auth"
@@ -32,6 +33,22 @@ type ExecuteResult struct {
 	ExitCode int
 }
 
+// ChunkStream identifies which output stream a Chunk came from.
+type ChunkStream string
+
+const (
+	ChunkStdout ChunkStream = "stdout"
+	ChunkStderr ChunkStream = "stderr"
+)
+
+// Chunk is a single line of live output produced while a command runs,
+// handed to Execute's chunks channel so a caller can stream it to
+// subscribers before the command finishes.
+type Chunk struct {
+	Stream ChunkStream
+	Line   string
+}
+
 // shellQuote properly quotes a string for shell usage
 func shellQuote(s string) string {
 	if s == "" {
@@ -42,34 +59,46 @@ func shellQuote(s string) string {
 	return "'" + s + "'"
 }
 
-func (e *CLIExecutor) Execute(command string, args []string) ExecuteResult {
+// Execute runs command, sending any incremental output produced along the
+// way to chunks so a caller can stream it to subscribers before the command
+// finishes. chunks may be nil, in which case output is only available on
+// the returned ExecuteResult once the command completes. Sends respect ctx,
+// so a cancelled job doesn't block here waiting for a slow reader.
+func (e *CLIExecutor) Execute(ctx context.Context, command string, args []string, chunks chan<- Chunk) (*ExecuteResult, error) {
 	// Initialize Plandex environment (must be done before calling functions)
-	err := e.initializePlandexEnvironment()
-	if err != nil {
-		return ExecuteResult{
-			Output:   "",
-			Error:    err.Error(),
-			ExitCode: 1,
+	if err := e.initializePlandexEnvironment(); err != nil {
+		return nil, err
+	}
+
+	send := func(stream ChunkStream, line string) {
+		if chunks == nil {
+			return
+		}
+		select {
+		case chunks <- Chunk{Stream: stream, Line: line}:
+		case <-ctx.Done():
 		}
 	}
 
 	// Handle different commands by calling Plandex Go functions directly
+	var result ExecuteResult
 	switch command {
 	case "tell":
-		return e.executeTell(args)
+		result = e.executeTell(args, send)
 	case "chat":
-		return e.executeChat(args)
+		result = e.executeChat(args, send)
 	case "models":
-		return e.executeModels(args)
+		result = e.executeModels(args, send)
 	case "plans":
-		return e.executePlans(args)
+		result = e.executePlans(args, send)
 	default:
-		return ExecuteResult{
+		result = ExecuteResult{
 			Output:   "",
 			Error:    "Unknown command: " + command,
 			ExitCode: 1,
 		}
 	}
+	return &result, nil
 }
 
 func (e *CLIExecutor) initializePlandexEnvironment() error {
@@ -93,7 +122,7 @@ func (e *CLIExecutor) initializePlandexEnvironment() error {
 	return nil
 }
 
-func (e *CLIExecutor) executeTell(args []string) ExecuteResult {
+func (e *CLIExecutor) executeTell(args []string, send func(ChunkStream, string)) ExecuteResult {
 	if len(args) == 0 {
 		return ExecuteResult{
 			Output:   "",
@@ -103,6 +132,7 @@ func (e *CLIExecutor) executeTell(args []string) ExecuteResult {
 	}
 
 	prompt := strings.Join(args, " ")
+	send(ChunkStdout, "running tell: "+prompt)
 
 	// Call the actual Plandex TellPlan function
 	plan_exec.TellPlan(plan_exec.ExecParams{
@@ -119,6 +149,7 @@ func (e *CLIExecutor) executeTell(args []string) ExecuteResult {
 		SkipChangesMenu: true,  // Skip interactive menus
 	})
 
+	send(ChunkStdout, "tell command executed successfully")
 	return ExecuteResult{
 		Output:   "Tell command executed successfully",
 		Error:    "",
@@ -126,7 +157,7 @@ func (e *CLIExecutor) executeTell(args []string) ExecuteResult {
 	}
 }
 
-func (e *CLIExecutor) executeChat(args []string) ExecuteResult {
+func (e *CLIExecutor) executeChat(args []string, send func(ChunkStream, string)) ExecuteResult {
 	if len(args) == 0 {
 		return ExecuteResult{
 			Output:   "",
@@ -136,6 +167,7 @@ func (e *CLIExecutor) executeChat(args []string) ExecuteResult {
 	}
 
 	prompt := strings.Join(args, " ")
+	send(ChunkStdout, "running chat: "+prompt)
 
 	// Call TellPlan with IsChatOnly flag for chat mode
 	plan_exec.TellPlan(plan_exec.ExecParams{
@@ -151,6 +183,7 @@ func (e *CLIExecutor) executeChat(args []string) ExecuteResult {
 		SkipChangesMenu: true,
 	})
 
+	send(ChunkStdout, "chat command executed successfully")
 	return ExecuteResult{
 		Output:   "Chat command executed successfully",
 		Error:    "",
@@ -158,8 +191,9 @@ func (e *CLIExecutor) executeChat(args []string) ExecuteResult {
 	}
 }
 
-func (e *CLIExecutor) executeModels(args []string) ExecuteResult {
+func (e *CLIExecutor) executeModels(args []string, send func(ChunkStream, string)) ExecuteResult {
 	// For now, return a simple response - we can enhance this later
+	send(ChunkStdout, "models command - using direct Go function calls")
 	return ExecuteResult{
 		Output:   "Models command - using direct Go function calls",
 		Error:    "",
@@ -167,8 +201,9 @@ func (e *CLIExecutor) executeModels(args []string) ExecuteResult {
 	}
 }
 
-func (e *CLIExecutor) executePlans(args []string) ExecuteResult {
-	// For now, return a simple response - we can enhance this later  
+func (e *CLIExecutor) executePlans(args []string, send func(ChunkStream, string)) ExecuteResult {
+	// For now, return a simple response - we can enhance this later
+	send(ChunkStdout, "plans command - using direct Go function calls")
 	return ExecuteResult{
 		Output:   "Plans command - using direct Go function calls",
 		Error:    "",
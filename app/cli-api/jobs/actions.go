@@ -0,0 +1,202 @@
+package jobs
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"plandex-cli-api/config"
+)
+
+// ActionParam is a single named parameter extracted from an action's argv
+// template, e.g. the "{prompt:string,required}" or "{branch:string,
+// default=main}" placeholders in config.ActionConfig.ArgTemplate.
+type ActionParam struct {
+	Name     string
+	Type     string
+	Required bool
+	Default  string
+}
+
+// Action is a named, parameterized job template (see config.ActionConfig):
+// resolving it against a set of named parameters produces the concrete
+// Command/Args CreateJob actually runs.
+type Action struct {
+	Name          string
+	Command       string
+	Description   string
+	Params        []ActionParam
+	Timeout       time.Duration
+	MaxConcurrent int
+	WebhookURL    string
+
+	argTemplate []string
+}
+
+// resolveArgs expands a's argv template against params, applying declared
+// defaults and rejecting a call missing a required parameter.
+func (a *Action) resolveArgs(params map[string]string) ([]string, error) {
+	for _, p := range a.Params {
+		if _, ok := params[p.Name]; !ok && p.Required {
+			return nil, fmt.Errorf("action %s: missing required parameter %q", a.Name, p.Name)
+		}
+	}
+
+	args := make([]string, 0, len(a.argTemplate))
+	for _, tok := range a.argTemplate {
+		name, isPlaceholder := placeholderName(tok)
+		if !isPlaceholder {
+			args = append(args, tok)
+			continue
+		}
+
+		value, ok := params[name]
+		if !ok {
+			for _, p := range a.Params {
+				if p.Name == name {
+					value = p.Default
+				}
+			}
+		}
+		args = append(args, value)
+	}
+	return args, nil
+}
+
+// toCommandMapping renders a as a jobs.CommandMapping, so GET /actions can
+// reuse the same catalog shape the existing GET /commands endpoint does.
+func (a *Action) toCommandMapping() *CommandMapping {
+	mapping := &CommandMapping{
+		Name:        a.Name,
+		Description: a.Description,
+		Args:        make([]CommandArg, 0, len(a.Params)),
+	}
+	for _, p := range a.Params {
+		mapping.Args = append(mapping.Args, CommandArg{
+			Name:     p.Name,
+			Required: p.Required,
+			Type:     p.Type,
+		})
+	}
+	return mapping
+}
+
+// ActionRegistry resolves named actions (see config.Config.Actions) into
+// concrete Command/Args pairs, so a client can invoke a stable action name
+// instead of knowing Plandex's exact CLI vocabulary and argument order.
+type ActionRegistry struct {
+	actions map[string]*Action
+}
+
+// NewActionRegistry builds a registry from cfgActions, parsing each entry's
+// argv template up front so a malformed placeholder is caught at startup
+// rather than on a client's first request.
+func NewActionRegistry(cfgActions []config.ActionConfig) (*ActionRegistry, error) {
+	r := &ActionRegistry{actions: make(map[string]*Action, len(cfgActions))}
+
+	for _, ac := range cfgActions {
+		params, err := parseArgTemplate(ac.ArgTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("action %s: %w", ac.Name, err)
+		}
+		r.actions[ac.Name] = &Action{
+			Name:          ac.Name,
+			Command:       ac.Command,
+			Description:   ac.Description,
+			Params:        params,
+			Timeout:       ac.Timeout,
+			MaxConcurrent: ac.MaxConcurrent,
+			WebhookURL:    ac.WebhookURL,
+			argTemplate:   ac.ArgTemplate,
+		}
+	}
+
+	return r, nil
+}
+
+// Resolve looks up name and expands its argv template against params into a
+// concrete Command/Args pair for CreateJob.
+func (r *ActionRegistry) Resolve(name string, params map[string]string) (command string, args []string, err error) {
+	a, ok := r.actions[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown action: %s", name)
+	}
+	args, err = a.resolveArgs(params)
+	if err != nil {
+		return "", nil, err
+	}
+	return a.Command, args, nil
+}
+
+// Get returns the named action, if registered.
+func (r *ActionRegistry) Get(name string) (*Action, bool) {
+	a, ok := r.actions[name]
+	return a, ok
+}
+
+// List returns every registered action rendered as a CommandMapping, in no
+// particular order, for GET /actions.
+func (r *ActionRegistry) List() []*CommandMapping {
+	out := make([]*CommandMapping, 0, len(r.actions))
+	for _, a := range r.actions {
+		out = append(out, a.toCommandMapping())
+	}
+	return out
+}
+
+// parseArgTemplate extracts the named parameters declared by an action's
+// argv template placeholders.
+func parseArgTemplate(template []string) ([]ActionParam, error) {
+	var params []ActionParam
+	for _, tok := range template {
+		p, isPlaceholder, err := parsePlaceholder(tok)
+		if err != nil {
+			return nil, err
+		}
+		if isPlaceholder {
+			params = append(params, *p)
+		}
+	}
+	return params, nil
+}
+
+// placeholderName reports the parameter name bound by tok, if tok is a
+// "{name:type,...}" placeholder.
+func placeholderName(tok string) (name string, isPlaceholder bool) {
+	if !strings.HasPrefix(tok, "{") || !strings.HasSuffix(tok, "}") {
+		return "", false
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(tok, "{"), "}")
+	name, _, _ = strings.Cut(body, ":")
+	return name, true
+}
+
+// parsePlaceholder parses a "{name:type,required,default=value}" argv
+// template token into an ActionParam. A token without surrounding braces
+// isn't a placeholder at all, and is reported as such rather than an error.
+func parsePlaceholder(tok string) (*ActionParam, bool, error) {
+	if !strings.HasPrefix(tok, "{") || !strings.HasSuffix(tok, "}") {
+		return nil, false, nil
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(tok, "{"), "}")
+	parts := strings.Split(body, ",")
+
+	name, typ, ok := strings.Cut(parts[0], ":")
+	if !ok || name == "" || typ == "" {
+		return nil, false, fmt.Errorf("malformed action parameter %q: expected {name:type,...}", tok)
+	}
+
+	p := &ActionParam{Name: name, Type: typ}
+	for _, flag := range parts[1:] {
+		switch {
+		case flag == "required":
+			p.Required = true
+		case strings.HasPrefix(flag, "default="):
+			p.Default = strings.TrimPrefix(flag, "default=")
+		default:
+			return nil, false, fmt.Errorf("malformed action parameter %q: unknown modifier %q", tok, flag)
+		}
+	}
+	return p, true, nil
+}
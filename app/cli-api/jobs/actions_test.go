@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"testing"
+
+	"plandex-cli-api/config"
+)
+
+// TestActionRegistry_ResolveExpandsTemplate asserts a registered action's
+// argv template expands required/default parameters correctly.
+func TestActionRegistry_ResolveExpandsTemplate(t *testing.T) {
+	registry, err := NewActionRegistry([]config.ActionConfig{
+		{
+			Name:        "ask",
+			Command:     "tell",
+			ArgTemplate: []string{"{prompt:string,required}", "--branch", "{branch:string,default=main}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewActionRegistry: %v", err)
+	}
+
+	command, args, err := registry.Resolve("ask", map[string]string{"prompt": "add a login form"})
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if command != "tell" {
+		t.Fatalf("expected command %q, got %q", "tell", command)
+	}
+	wantArgs := []string{"add a login form", "--branch", "main"}
+	if len(args) != len(wantArgs) {
+		t.Fatalf("expected args %v, got %v", wantArgs, args)
+	}
+	for i, a := range wantArgs {
+		if args[i] != a {
+			t.Fatalf("expected args %v, got %v", wantArgs, args)
+		}
+	}
+}
+
+// TestActionRegistry_ResolveRejectsMissingRequiredParam asserts Resolve
+// errors when a required parameter isn't supplied.
+func TestActionRegistry_ResolveRejectsMissingRequiredParam(t *testing.T) {
+	registry, err := NewActionRegistry([]config.ActionConfig{
+		{Name: "ask", Command: "tell", ArgTemplate: []string{"{prompt:string,required}"}},
+	})
+	if err != nil {
+		t.Fatalf("NewActionRegistry: %v", err)
+	}
+
+	if _, _, err := registry.Resolve("ask", map[string]string{}); err == nil {
+		t.Fatal("expected missing required parameter to be rejected")
+	}
+}
+
+// TestActionRegistry_ResolveRejectsUnknownAction asserts Resolve errors for
+// a name that isn't registered.
+func TestActionRegistry_ResolveRejectsUnknownAction(t *testing.T) {
+	registry, err := NewActionRegistry(nil)
+	if err != nil {
+		t.Fatalf("NewActionRegistry: %v", err)
+	}
+
+	if _, _, err := registry.Resolve("missing", nil); err == nil {
+		t.Fatal("expected unknown action to be rejected")
+	}
+}
+
+// TestNewActionRegistry_RejectsMalformedTemplate asserts a malformed
+// placeholder is caught at registry construction, not at resolve time.
+func TestNewActionRegistry_RejectsMalformedTemplate(t *testing.T) {
+	_, err := NewActionRegistry([]config.ActionConfig{
+		{Name: "ask", Command: "tell", ArgTemplate: []string{"{prompt}"}},
+	})
+	if err == nil {
+		t.Fatal("expected malformed argv template to be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package jobs
+
+import (
+	"container/heap"
+	"time"
+)
+
+// ageEntry tracks one job's position in ageQueue, so it can be removed in
+// O(log n) once it's no longer the oldest job needing eviction.
+type ageEntry struct {
+	id        string
+	createdAt time.Time
+	index     int
+}
+
+// ageQueue is a min-heap of jobs ordered by CreatedAt, letting
+// cleanupExpiredJobs evict the oldest jobs in O(log n) per removal instead
+// of resorting every job in the manager by creation time.
+type ageQueue []*ageEntry
+
+func (q ageQueue) Len() int { return len(q) }
+
+func (q ageQueue) Less(i, j int) bool {
+	if q[i].createdAt.Equal(q[j].createdAt) {
+		return q[i].id < q[j].id
+	}
+	return q[i].createdAt.Before(q[j].createdAt)
+}
+
+func (q ageQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *ageQueue) Push(x interface{}) {
+	entry := x.(*ageEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *ageQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// trackAgeLocked adds id to the age queue. Callers must hold m.jobsMutex.
+func (m *Manager) trackAgeLocked(id string, createdAt time.Time) {
+	if _, exists := m.ageIndex[id]; exists {
+		return
+	}
+	entry := &ageEntry{id: id, createdAt: createdAt}
+	heap.Push(&m.ageQueue, entry)
+	m.ageIndex[id] = entry
+}
+
+// untrackAgeLocked removes id from the age queue, if present. Callers must
+// hold m.jobsMutex.
+func (m *Manager) untrackAgeLocked(id string) {
+	entry, ok := m.ageIndex[id]
+	if !ok {
+		return
+	}
+	heap.Remove(&m.ageQueue, entry.index)
+	delete(m.ageIndex, id)
+}
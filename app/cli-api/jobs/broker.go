@@ -0,0 +1,121 @@
+package jobs
+
+import "sync"
+
+// BrokerEventKind identifies which kind of frame a Broker subscriber
+// receives, matching the SSE/WS event names the stream endpoints expose.
+type BrokerEventKind string
+
+const (
+	BrokerEventLog    BrokerEventKind = "log"
+	BrokerEventStatus BrokerEventKind = "status"
+	BrokerEventDone   BrokerEventKind = "done"
+)
+
+// BrokerEvent is a single frame delivered to a job's /stream and /ws
+// subscribers. Log carries the line for BrokerEventLog; Status and
+// ExitCode carry the job's terminal state for BrokerEventStatus/Done.
+type BrokerEvent struct {
+	Kind     BrokerEventKind
+	Log      *LogEntry
+	Status   JobStatus
+	ExitCode *int
+}
+
+// Broker fans out a job's live stdout/stderr (appended via job.AppendLog as
+// the executor streams Chunks to it) plus status/done transitions to any
+// number of stream/ws subscribers. Log replay for late joiners reuses the
+// job's own logBuffer; status/done frames are only ever live, since a job's
+// current status is already visible from GetJob.
+type Broker struct {
+	job *Job
+
+	mu          sync.Mutex
+	subscribers map[int]chan BrokerEvent
+	nextSubID   int
+}
+
+func newBroker(job *Job) *Broker {
+	return &Broker{job: job, subscribers: make(map[int]chan BrokerEvent)}
+}
+
+// publishStatus broadcasts a status-change frame to every live subscriber.
+func (b *Broker) publishStatus(status JobStatus) {
+	b.broadcast(BrokerEvent{Kind: BrokerEventStatus, Status: status})
+}
+
+// publishDone broadcasts the terminal frame once the job finishes. It does
+// not close subscriber channels; stream handlers end on receiving it.
+func (b *Broker) publishDone(status JobStatus, exitCode *int) {
+	b.broadcast(BrokerEvent{Kind: BrokerEventDone, Status: status, ExitCode: exitCode})
+}
+
+func (b *Broker) broadcast(evt BrokerEvent) {
+	b.mu.Lock()
+	subs := make([]chan BrokerEvent, 0, len(b.subscribers))
+	for _, ch := range b.subscribers {
+		subs = append(subs, ch)
+	}
+	b.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a live subscriber that receives every log line
+// appended from this point on, plus status/done frames, until cancel is
+// called. Like the underlying logBuffer, a slow subscriber has frames
+// dropped rather than blocking the job.
+func (b *Broker) Subscribe() (<-chan BrokerEvent, func()) {
+	b.mu.Lock()
+	id := b.nextSubID
+	b.nextSubID++
+	ch := make(chan BrokerEvent, 64)
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	logCh, cancelLogs := b.job.SubscribeLogs()
+	stop := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case entry, ok := <-logCh:
+				if !ok {
+					return
+				}
+				e := entry
+				select {
+				case ch <- BrokerEvent{Kind: BrokerEventLog, Log: &e}:
+				default:
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return ch, func() {
+		close(stop)
+		cancelLogs()
+		b.mu.Lock()
+		delete(b.subscribers, id)
+		b.mu.Unlock()
+	}
+}
+
+// ReplaySince returns every buffered log line since cursor as log frames,
+// letting a reconnecting client catch up before following live via
+// Subscribe.
+func (b *Broker) ReplaySince(since int) []BrokerEvent {
+	entries := b.job.LogsSince(since)
+	out := make([]BrokerEvent, len(entries))
+	for i := range entries {
+		e := entries[i]
+		out[i] = BrokerEvent{Kind: BrokerEventLog, Log: &e}
+	}
+	return out
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import "testing"
+
+// TestBroker_ReplaysBufferedLogsThenFollowsLive asserts a subscriber that
+// joins mid-job first sees buffered lines via ReplaySince, then live lines
+// and the terminal done frame once the job finishes, matching what the
+// /stream and /ws endpoints rely on.
+func TestBroker_ReplaysBufferedLogsThenFollowsLive(t *testing.T) {
+	job := &Job{logs: newLogBuffer(100)}
+	job.AppendLog(LogStreamStdout, "buffered line")
+
+	broker := job.Broker()
+	replayed := broker.ReplaySince(-1)
+	if len(replayed) != 1 || replayed[0].Log.Line != "buffered line" {
+		t.Fatalf("expected replay to contain the buffered line, got %+v", replayed)
+	}
+
+	events, cancel := broker.Subscribe()
+	defer cancel()
+
+	job.AppendLog(LogStreamStdout, "live line")
+	evt := <-events
+	if evt.Kind != BrokerEventLog || evt.Log.Line != "live line" {
+		t.Fatalf("expected a live log event, got %+v", evt)
+	}
+
+	job.Status = JobStatusCompleted
+	exitCode := 0
+	job.ExitCode = &exitCode
+	broker.publishDone(job.Status, job.ExitCode)
+
+	evt = <-events
+	if evt.Kind != BrokerEventDone || evt.Status != JobStatusCompleted || *evt.ExitCode != 0 {
+		t.Fatalf("expected a done event with exit code 0, got %+v", evt)
+	}
+}
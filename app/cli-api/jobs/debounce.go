@@ -0,0 +1,78 @@
+package jobs
+
+import (
+	"strings"
+	"time"
+)
+
+// debounceKey canonically identifies jobs that are "the same request": same
+// command, same args, against the same plan. Two requests sharing a key are
+// coalesced rather than run concurrently, since running them side by side
+// against the same plan would race on its context.
+type debounceKey struct {
+	command string
+	argsKey string
+	planID  string
+}
+
+// newDebounceKey builds the canonical key for a command+args+metadata combo.
+// metadata's "plan_id" field (if present) scopes the key to a single plan, so
+// identical commands against different plans never collide.
+func newDebounceKey(command string, args []string, metadata map[string]interface{}) debounceKey {
+	planID, _ := metadata["plan_id"].(string)
+	return debounceKey{
+		command: command,
+		argsKey: strings.Join(args, "\x00"),
+		planID:  planID,
+	}
+}
+
+// debounceBacklog coalesces back-to-back requests for the same debounceKey
+// that arrive within the configured debounce window. A single worker
+// goroutine reads requests off reqCh and only actually starts a job once the
+// window has passed without a newer request replacing it, so a burst of N
+// identical requests results in exactly one execution, matching the
+// debounce-with-channels pattern used elsewhere for backlog draining.
+type debounceBacklog struct {
+	reqCh chan *Job
+}
+
+// newDebounceBacklog starts the worker goroutine and returns the backlog.
+// start is called with the latest queued job once the debounce window has
+// elapsed without a newer one superseding it.
+func newDebounceBacklog(window time.Duration, start func(*Job)) *debounceBacklog {
+	b := &debounceBacklog{reqCh: make(chan *Job)}
+	go b.run(window, start)
+	return b
+}
+
+func (b *debounceBacklog) run(window time.Duration, start func(*Job)) {
+	var pending *Job
+	var timer *time.Timer
+	var fire <-chan time.Time
+
+	for {
+		select {
+		case job, ok := <-b.reqCh:
+			if !ok {
+				return
+			}
+			pending = job
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.NewTimer(window)
+			fire = timer.C
+		case <-fire:
+			start(pending)
+			pending = nil
+			fire = nil
+		}
+	}
+}
+
+// enqueue offers job to the backlog, replacing whatever request was
+// previously queued for this key but not yet started.
+func (b *debounceBacklog) enqueue(job *Job) {
+	b.reqCh <- job
+}
@@ -0,0 +1,111 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// LogStream identifies which output stream a log entry came from.
+type LogStream string
+
+const (
+	LogStreamStdout LogStream = "stdout"
+	LogStreamStderr LogStream = "stderr"
+	LogStreamSystem LogStream = "system"
+)
+
+// LogEntry is a single line of job output, tagged with a monotonically
+// increasing cursor so clients can resume a stream with `?since=<cursor>`.
+type LogEntry struct {
+	Cursor    int       `json:"cursor"`
+	Timestamp time.Time `json:"timestamp"`
+	Stream    LogStream `json:"stream"`
+	Line      string    `json:"line"`
+}
+
+// logBuffer is a bounded ring buffer of a job's log output. It replays the
+// last N lines to late subscribers and fans out new lines to live tailers.
+type logBuffer struct {
+	mu          sync.Mutex
+	maxLines    int
+	entries     []LogEntry
+	nextCursor  int
+	subscribers map[int]chan LogEntry
+	nextSubID   int
+}
+
+// newLogBuffer creates a log buffer that retains at most maxLines entries.
+func newLogBuffer(maxLines int) *logBuffer {
+	if maxLines <= 0 {
+		maxLines = 1000
+	}
+	return &logBuffer{
+		maxLines:    maxLines,
+		subscribers: make(map[int]chan LogEntry),
+	}
+}
+
+// append records a line and fans it out to any live subscribers. Slow
+// subscribers have frames dropped rather than blocking the caller.
+func (b *logBuffer) append(stream LogStream, line string) LogEntry {
+	b.mu.Lock()
+	entry := LogEntry{
+		Cursor:    b.nextCursor,
+		Timestamp: time.Now(),
+		Stream:    stream,
+		Line:      line,
+	}
+	b.nextCursor++
+
+	b.entries = append(b.entries, entry)
+	if len(b.entries) > b.maxLines {
+		b.entries = b.entries[len(b.entries)-b.maxLines:]
+	}
+
+	subs := make([]chan LogEntry, 0, len(b.subscribers))
+	for _, ch := range b.subscribers {
+		subs = append(subs, ch)
+	}
+	b.mu.Unlock()
+
+	for _, ch := range subs {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+
+	return entry
+}
+
+// since returns every buffered entry with a cursor greater than since.
+func (b *logBuffer) since(cursor int) []LogEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var out []LogEntry
+	for _, e := range b.entries {
+		if e.Cursor > cursor {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// subscribe registers a channel that receives every entry appended after
+// this call. The returned cancel func must be called once the subscriber is
+// done to avoid leaking the channel.
+func (b *logBuffer) subscribe() (<-chan LogEntry, func()) {
+	b.mu.Lock()
+	id := b.nextSubID
+	b.nextSubID++
+	ch := make(chan LogEntry, 64)
+	b.subscribers[id] = ch
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subscribers, id)
+		b.mu.Unlock()
+	}
+}
@@ -1,10 +1,13 @@
 package jobs
 
 import (
+	"container/heap"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,70 +17,636 @@ import (
 	"plandex-cli-api/webhooks"
 )
 
+// ErrDraining is returned by CreateJob once the manager has started
+// draining for shutdown.
+var ErrDraining = errors.New("server is draining: not accepting new jobs")
+
 // Manager handles job lifecycle and execution
 type Manager struct {
-	config        *config.Config
-	jobs          map[string]*Job
-	jobsMutex     sync.RWMutex
-	running       map[string]context.CancelFunc
-	runningMutex  sync.RWMutex
-	semaphore     chan struct{}
-	webhookSender *webhooks.Sender
-	ctx           context.Context
-	cancel        context.CancelFunc
-	executor      *executor.CLIExecutor
-}
-
-// NewManager creates a new job manager
-func NewManager(cfg *config.Config) *Manager {
+	config            *config.Config
+	jobs              map[string]*Job
+	jobsMutex         sync.RWMutex
+	running           map[string]context.CancelFunc
+	runningMutex      sync.RWMutex
+	webhookSender     *webhooks.Sender
+	webhookDispatcher *webhooks.Dispatcher
+	ctx               context.Context
+	cancel            context.CancelFunc
+	executor          *executor.CLIExecutor
+	store             Store
+	actions           *ActionRegistry
+
+	// scheduler admits queued jobs for execution in (Priority desc,
+	// CreatedAt asc) order, subject to config.Jobs.MaxConcurrent and
+	// MaxConcurrentPerTenant.
+	scheduler *scheduler
+
+	// actionSemaphores holds one buffered channel per action with a
+	// MaxConcurrent cap, built once at startup from config.Actions; a job
+	// created via such an action acquires a slot in addition to the
+	// scheduler's global and per-tenant caps.
+	actionSemaphores map[string]chan struct{}
+
+	// ageQueue/ageIndex track every in-memory job by CreatedAt, letting
+	// cleanupExpiredJobs evict the oldest jobs past MaxHistorySize in
+	// O(log n) per removal instead of resorting the whole map. Callers must
+	// hold jobsMutex.
+	ageQueue ageQueue
+	ageIndex map[string]*ageEntry
+
+	// durationMu guards avgDuration, an exponential moving average of
+	// recent job run times used to estimate queue wait time (see
+	// QueueStats).
+	durationMu  sync.Mutex
+	avgDuration time.Duration
+
+	draining int32 // atomic bool; set once shutdown begins
+	jobsWG   sync.WaitGroup
+
+	// createMutex serializes the idempotency/debounce decision in CreateJob
+	// so that concurrent identical requests can't race past the
+	// check-then-register step and each spawn their own job.
+	createMutex      sync.Mutex
+	idempotencyKeys  map[string]string // idempotency key -> job ID, cleared once the job reaches a terminal state
+	debouncePending  map[debounceKey]*Job
+	debounceBacklogs map[debounceKey]*debounceBacklog
+
+	// debounceRunning tracks the job currently executing for a debounce key,
+	// and debounceNext the single job (if any) queued to start the moment it
+	// finishes - further requests for the same key while one is running
+	// collapse into debounceNext rather than stacking up more executions.
+	debounceRunning map[debounceKey]*Job
+	debounceNext    map[debounceKey]*Job
+}
+
+// defaultAlwaysDebounceWindow is the debounce window used for commands named
+// in config.Jobs.DebounceAlwaysCommands when config.Jobs.DebounceWindow
+// itself is unset.
+const defaultAlwaysDebounceWindow = 2 * time.Second
+
+// NewManager creates a new job manager, opening its job store (see
+// config.Jobs.StorePath) and rehydrating whatever jobs survived the last
+// restart.
+func NewManager(cfg *config.Config) (*Manager, error) {
+	store, err := NewStore(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store: %w", err)
+	}
+
+	actions, err := NewActionRegistry(cfg.Actions)
+	if err != nil {
+		store.Close()
+		return nil, fmt.Errorf("failed to load action registry: %w", err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	m := &Manager{
-		config:        cfg,
-		jobs:          make(map[string]*Job),
-		running:       make(map[string]context.CancelFunc),
-		semaphore:     make(chan struct{}, cfg.Jobs.MaxConcurrent),
-		webhookSender: webhooks.NewSender(cfg),
-		ctx:           ctx,
-		cancel:        cancel,
-		executor:      executor.NewCLIExecutor(cfg.CLI.WorkingDir, cfg.CLI.ProjectPath, cfg.CLI.APIKeys, cfg.CLI.Environment),
+		config:            cfg,
+		jobs:              make(map[string]*Job),
+		running:           make(map[string]context.CancelFunc),
+		webhookSender:     webhooks.NewSender(cfg),
+		webhookDispatcher: webhooks.NewDispatcher(cfg, store),
+		ctx:               ctx,
+		cancel:            cancel,
+		executor:          executor.NewCLIExecutor(cfg.CLI.WorkingDir, cfg.CLI.ProjectPath, cfg.CLI.APIKeys, cfg.CLI.Environment),
+		idempotencyKeys:   make(map[string]string),
+		debouncePending:   make(map[debounceKey]*Job),
+		debounceBacklogs:  make(map[debounceKey]*debounceBacklog),
+		debounceRunning:   make(map[debounceKey]*Job),
+		debounceNext:      make(map[debounceKey]*Job),
+		store:             store,
+		actions:           actions,
+		actionSemaphores:  make(map[string]chan struct{}),
+		ageIndex:          make(map[string]*ageEntry),
+	}
+	m.scheduler = newScheduler(cfg.Jobs.MaxConcurrent, cfg.Jobs.MaxConcurrentPerTenant, m.dispatchJob)
+
+	for _, ac := range cfg.Actions {
+		if ac.MaxConcurrent > 0 {
+			m.actionSemaphores[ac.Name] = make(chan struct{}, ac.MaxConcurrent)
+		}
 	}
 
-	// Start cleanup routine
+	m.rehydrate()
+
+	// Start the scheduler and cleanup routines
+	go m.scheduler.run(m.ctx)
 	go m.cleanupRoutine()
 
-	return m
+	return m, nil
 }
 
-// CreateJob creates a new job from a request
-func (m *Manager) CreateJob(req *JobRequest) (*Job, error) {
+// rehydrate walks the job store on startup and reconstructs m.jobs from
+// whatever survived the last restart. A job still pending/running older
+// than config.Jobs.InterruptedThreshold is marked failed with "interrupted
+// by restart" - this process's goroutine and context for it are gone
+// regardless of how close it was to finishing. Any terminal job whose
+// webhook was never marked delivered gets its terminal event re-fired.
+func (m *Manager) rehydrate() {
+	if m.store == nil {
+		return
+	}
+
+	records, err := m.store.List()
+	if err != nil {
+		log.Printf("failed to list job store for rehydration: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var toRefire []*Job
+
+	for _, rec := range records {
+		job := fromRecord(rec, m.config.Jobs.LogBufferLines)
+
+		if !job.IsComplete() {
+			age := now.Sub(job.CreatedAt)
+			if job.StartedAt != nil {
+				age = now.Sub(*job.StartedAt)
+			}
+			if age >= m.config.Jobs.InterruptedThreshold {
+				job.Status = JobStatusFailed
+				job.Error = "interrupted by restart"
+				completedAt := now
+				job.CompletedAt = &completedAt
+				exitCode := 1
+				job.ExitCode = &exitCode
+				job.WebhookDelivered = false
+				job.AppendLog(LogStreamSystem, "job interrupted by restart")
+			}
+		}
+
+		m.jobsMutex.Lock()
+		m.jobs[job.ID] = job
+		m.trackAgeLocked(job.ID, job.CreatedAt)
+		m.persistLocked(job)
+		m.jobsMutex.Unlock()
+
+		if job.IsComplete() && !job.WebhookDelivered {
+			toRefire = append(toRefire, job)
+		}
+	}
+
+	for _, job := range toRefire {
+		log.Printf("re-firing undelivered terminal webhook for job %s", job.ID)
+		m.refireTerminalWebhook(job)
+	}
+
+	if len(records) > 0 {
+		log.Printf("rehydrated %d job(s) from store", len(records))
+	}
+}
+
+// refireTerminalWebhook re-sends a job's terminal lifecycle event, used for
+// jobs rehydrated from the store whose WebhookDelivered bit was never set.
+func (m *Manager) refireTerminalWebhook(job *Job) {
+	terminalEvent := webhooks.EventJobCompleted
+	switch job.Status {
+	case JobStatusFailed:
+		terminalEvent = webhooks.EventJobFailed
+	case JobStatusCancelled:
+		terminalEvent = webhooks.EventJobCancelled
+	}
+
+	if job.WebhookURL != "" {
+		update := &webhooks.JobStatusUpdate{
+			JobID:       job.ID,
+			Status:      string(job.Status),
+			CompletedAt: job.CompletedAt,
+			Output:      job.Output,
+			Error:       job.Error,
+			ExitCode:    job.ExitCode,
+			Metadata:    job.Metadata,
+		}
+		go m.webhookSender.Send(job.WebhookURL, update)
+	}
+
+	m.emitEvent(terminalEvent, job)
+	m.markWebhookDelivered(job)
+}
+
+// persistLocked write-throughs job to the store, if any. Callers must
+// already hold m.jobsMutex. Persistence errors are logged rather than
+// returned so a degraded store never blocks a job's goroutine.
+func (m *Manager) persistLocked(job *Job) {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Save(toRecordLocked(job)); err != nil {
+		log.Printf("job %s: failed to persist to job store: %v", job.ID, err)
+	}
+}
+
+// markWebhookDelivered flips a job's WebhookDelivered bit and persists it,
+// so a restart before this point can tell its terminal event still needs
+// to be re-fired.
+func (m *Manager) markWebhookDelivered(job *Job) {
+	m.jobsMutex.Lock()
+	job.WebhookDelivered = true
+	m.persistLocked(job)
+	m.jobsMutex.Unlock()
+}
+
+// toRecordLocked snapshots job into its persisted representation. Callers
+// must already hold m.jobsMutex (for read or write).
+func toRecordLocked(job *Job) *JobRecord {
+	return &JobRecord{
+		ID:               job.ID,
+		Command:          job.Command,
+		Args:             append([]string(nil), job.Args...),
+		Status:           job.Status,
+		CreatedAt:        job.CreatedAt,
+		StartedAt:        job.StartedAt,
+		CompletedAt:      job.CompletedAt,
+		Output:           job.Output,
+		Error:            job.Error,
+		ExitCode:         job.ExitCode,
+		Metadata:         job.Metadata,
+		WebhookURL:       job.WebhookURL,
+		TTL:              job.TTL,
+		IdempotencyKey:   job.IdempotencyKey,
+		Action:           job.Action,
+		Priority:         job.Priority,
+		Tenant:           job.Tenant,
+		WebhookDelivered: job.WebhookDelivered,
+		RetryPolicy:      job.RetryPolicy,
+		Attempt:          job.Attempt,
+	}
+}
+
+// fromRecord rebuilds an in-memory Job (with a fresh log buffer; log lines
+// themselves aren't persisted) from a stored JobRecord.
+func fromRecord(rec *JobRecord, logBufferLines int) *Job {
+	return &Job{
+		ID:               rec.ID,
+		Command:          rec.Command,
+		Args:             rec.Args,
+		Status:           rec.Status,
+		CreatedAt:        rec.CreatedAt,
+		StartedAt:        rec.StartedAt,
+		CompletedAt:      rec.CompletedAt,
+		Output:           rec.Output,
+		Error:            rec.Error,
+		ExitCode:         rec.ExitCode,
+		Metadata:         rec.Metadata,
+		WebhookURL:       rec.WebhookURL,
+		TTL:              rec.TTL,
+		IdempotencyKey:   rec.IdempotencyKey,
+		Action:           rec.Action,
+		Priority:         rec.Priority,
+		Tenant:           rec.Tenant,
+		WebhookDelivered: rec.WebhookDelivered,
+		RetryPolicy:      rec.RetryPolicy,
+		Attempt:          rec.Attempt,
+		logs:             newLogBuffer(logBufferLines),
+	}
+}
+
+// CreateJob creates a new job from a request. If req.Action is set, it's
+// resolved through the manager's ActionRegistry into a concrete Command/Args
+// pair (see resolveAction) before anything else; a raw Command is rejected
+// outright if config.Jobs.RequireActions is set. If req.IdempotencyKey
+// matches a pending or running job, that existing job is returned instead of
+// creating a duplicate (existed reports this). Otherwise, for commands that
+// debounce (see shouldDebounce), a request is coalesced into whatever other
+// job already occupies its (command, args, plan_id) key rather than running
+// alongside it:
+//   - if that job is still queued (hasn't started), its args/metadata are
+//     replaced and the debounce window resets;
+//   - if that job is already running, the request is folded into the single
+//     "next" job queued to start the moment it finishes, collapsing any
+//     further duplicates that arrive in the meantime into the same next job.
+func (m *Manager) CreateJob(req *JobRequest) (job *Job, existed bool, err error) {
+	if m.IsDraining() {
+		return nil, false, ErrDraining
+	}
+
+	if err := m.resolveAction(req); err != nil {
+		return nil, false, err
+	}
+
 	if err := m.validateCommand(req.Command); err != nil {
-		return nil, fmt.Errorf("invalid command: %w", err)
+		return nil, false, fmt.Errorf("invalid command: %w", err)
 	}
 
-	job := &Job{
-		ID:         uuid.New().String(),
-		Command:    req.Command,
-		Args:       req.Args,
-		Status:     JobStatusPending,
-		CreatedAt:  time.Now(),
-		Metadata:   req.Metadata,
-		WebhookURL: req.WebhookURL,
-		TTL:        m.config.Jobs.DefaultTTL,
+	key := newDebounceKey(req.Command, req.Args, req.Metadata)
+	debounce := m.shouldDebounce(req.Command)
+
+	// The whole idempotency/debounce decision below must be atomic: two
+	// concurrent identical requests checking "does a job for this key
+	// already exist?" and then registering one if not is a classic
+	// check-then-act race, so it's serialized behind createMutex. Job
+	// execution itself is kicked off in a goroutine and happens outside the
+	// lock, so this never serializes the actual work.
+	m.createMutex.Lock()
+
+	if req.IdempotencyKey != "" {
+		if existingID, ok := m.idempotencyKeys[req.IdempotencyKey]; ok {
+			if existing, err := m.GetJob(existingID); err == nil && !existing.IsComplete() {
+				m.createMutex.Unlock()
+				return existing, true, nil
+			}
+			delete(m.idempotencyKeys, req.IdempotencyKey)
+		}
+	}
+
+	if debounce {
+		if existing, ok := m.debouncePending[key]; ok {
+			backlog := m.debounceBacklogs[key]
+			m.coalesceLocked(existing, req)
+			m.createMutex.Unlock()
+
+			existing.AppendLog(LogStreamSystem, fmt.Sprintf("coalesced request into pending job: %s %v", existing.Command, existing.Args))
+			backlog.enqueue(existing)
+			return existing, true, nil
+		}
+
+		if _, running := m.debounceRunning[key]; running {
+			if next, ok := m.debounceNext[key]; ok {
+				m.coalesceLocked(next, req)
+				m.createMutex.Unlock()
+
+				next.AppendLog(LogStreamSystem, fmt.Sprintf("coalesced request into queued job: %s %v", next.Command, next.Args))
+				return next, true, nil
+			}
+
+			next := m.newJob(req)
+			m.debounceNext[key] = next
+			m.createMutex.Unlock()
+
+			m.registerJob(next)
+			return next, false, nil
+		}
+	}
+
+	job = m.newJob(req)
+
+	var backlog *debounceBacklog
+	if debounce {
+		m.debouncePending[key] = job
+		var ok bool
+		backlog, ok = m.debounceBacklogs[key]
+		if !ok {
+			backlog = newDebounceBacklog(m.debounceWindowFor(req.Command), func(j *Job) { m.startDebouncedJob(key, j) })
+			m.debounceBacklogs[key] = backlog
+		}
+	}
+
+	m.createMutex.Unlock()
+
+	m.registerJob(job)
+
+	if backlog != nil {
+		backlog.enqueue(job)
+		return job, false, nil
+	}
+
+	// Submit job to the scheduler for execution asynchronously
+	m.jobsWG.Add(1)
+	m.scheduler.Submit(job)
+
+	return job, false, nil
+}
+
+// resolveAction resolves req.Action (if set) through the manager's
+// ActionRegistry into a concrete Command/Args pair, overwriting whatever
+// req.Command/req.Args were set to, and applies the action's default
+// webhook when req doesn't specify its own. A raw Command with no Action is
+// rejected when config.Jobs.RequireActions is set.
+func (m *Manager) resolveAction(req *JobRequest) error {
+	if req.Action == "" {
+		if m.config.Jobs.RequireActions {
+			return fmt.Errorf("raw commands are disabled: use a registered action (see GET /actions)")
+		}
+		return nil
 	}
 
+	command, args, err := m.actions.Resolve(req.Action, req.Params)
+	if err != nil {
+		return fmt.Errorf("invalid action: %w", err)
+	}
+	req.Command = command
+	req.Args = args
+
+	if req.WebhookURL == "" {
+		if action, ok := m.actions.Get(req.Action); ok {
+			req.WebhookURL = action.WebhookURL
+		}
+	}
+	return nil
+}
+
+// newJob builds a pending Job from req. Callers register it into m.jobs via
+// registerJob once any debounce-map bookkeeping under createMutex is done.
+func (m *Manager) newJob(req *JobRequest) *Job {
+	job := &Job{
+		ID:             uuid.New().String(),
+		Command:        req.Command,
+		Args:           req.Args,
+		Status:         JobStatusPending,
+		CreatedAt:      time.Now(),
+		Metadata:       req.Metadata,
+		WebhookURL:     req.WebhookURL,
+		TTL:            m.config.Jobs.DefaultTTL,
+		IdempotencyKey: req.IdempotencyKey,
+		Action:         req.Action,
+		Priority:       req.Priority,
+		Tenant:         req.Tenant,
+		RetryPolicy:    req.RetryPolicy,
+		Attempt:        1,
+		logs:           newLogBuffer(m.config.Jobs.LogBufferLines),
+	}
 	if req.TTL != nil {
 		job.TTL = *req.TTL
 	}
+	if req.IdempotencyKey != "" {
+		m.idempotencyKeys[req.IdempotencyKey] = job.ID
+	}
+	return job
+}
+
+// coalesceLocked folds a newer request into an already-queued job. Callers
+// must hold createMutex.
+func (m *Manager) coalesceLocked(existing *Job, req *JobRequest) {
+	m.jobsMutex.Lock()
+	existing.Args = req.Args
+	existing.Metadata = req.Metadata
+	existing.WebhookURL = req.WebhookURL
+	if req.IdempotencyKey != "" {
+		existing.IdempotencyKey = req.IdempotencyKey
+		m.idempotencyKeys[req.IdempotencyKey] = existing.ID
+	}
+	m.persistLocked(existing)
+	m.jobsMutex.Unlock()
+}
+
+// registerJob adds a newly created job to m.jobs and fires its created
+// event.
+func (m *Manager) registerJob(job *Job) {
+	job.AppendLog(LogStreamSystem, fmt.Sprintf("job %s created: %s %v", job.ID, job.Command, job.Args))
 
 	m.jobsMutex.Lock()
 	m.jobs[job.ID] = job
+	m.trackAgeLocked(job.ID, job.CreatedAt)
+	m.persistLocked(job)
 	m.jobsMutex.Unlock()
 
-	// Start job execution asynchronously
-	go m.executeJob(job)
+	m.emitEvent(webhooks.EventJobCreated, job)
+}
 
-	return job, nil
+// startDebouncedJob is called by a key's debounceBacklog once its window has
+// elapsed without a newer request superseding job, and actually dispatches
+// it for execution.
+func (m *Manager) startDebouncedJob(key debounceKey, job *Job) {
+	m.createMutex.Lock()
+	if m.debouncePending[key] == job {
+		delete(m.debouncePending, key)
+	}
+	m.createMutex.Unlock()
+
+	m.jobsWG.Add(1)
+	m.scheduler.Submit(job)
+}
+
+// shouldDebounce reports whether command's jobs should be coalesced against
+// others sharing its (command, args, plan_id) key, per config.Jobs.
+func (m *Manager) shouldDebounce(command string) bool {
+	for _, c := range m.config.Jobs.DebounceExcludeCommands {
+		if c == command {
+			return false
+		}
+	}
+	if m.config.Jobs.DebounceWindow > 0 {
+		return true
+	}
+	for _, c := range m.config.Jobs.DebounceAlwaysCommands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+// debounceWindowFor returns the debounce window to use for command: the
+// configured default, or defaultAlwaysDebounceWindow for a command that only
+// debounces via DebounceAlwaysCommands.
+func (m *Manager) debounceWindowFor(command string) time.Duration {
+	if m.config.Jobs.DebounceWindow > 0 {
+		return m.config.Jobs.DebounceWindow
+	}
+	return defaultAlwaysDebounceWindow
+}
+
+// shouldRetry reports whether job, having just finished attempt under
+// job.RetryPolicy, should be re-run in place rather than reported terminal.
+func (m *Manager) shouldRetry(job *Job, result *executor.ExecuteResult, err error, attempt int) bool {
+	policy := job.RetryPolicy
+	if policy == nil || attempt >= policy.MaxAttempts {
+		return false
+	}
+
+	exitCode := 0
+	failed := err != nil
+	if result != nil && result.ExitCode != 0 {
+		failed = true
+		exitCode = result.ExitCode
+	} else if err != nil {
+		exitCode = 1
+	}
+	if !failed {
+		return false
+	}
+
+	retryOn := policy.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = []JobStatus{JobStatusFailed}
+	}
+	if !containsStatus(retryOn, JobStatusFailed) {
+		return false
+	}
+
+	if len(policy.RetryableExitCodes) > 0 && !containsInt(policy.RetryableExitCodes, exitCode) {
+		return false
+	}
+
+	return true
+}
+
+// retryBackoff returns the delay before the given retry attempt, doubling
+// policy.Backoff for each attempt past the first.
+func retryBackoff(policy *RetryPolicy, attempt int) time.Duration {
+	backoff := policy.Backoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+func containsStatus(statuses []JobStatus, target JobStatus) bool {
+	for _, s := range statuses {
+		if s == target {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(ints []int, target int) bool {
+	for _, n := range ints {
+		if n == target {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDraining reports whether the manager is shutting down and rejecting new
+// jobs.
+func (m *Manager) IsDraining() bool {
+	return atomic.LoadInt32(&m.draining) == 1
+}
+
+// Actions returns the manager's ActionRegistry, so the server layer can
+// render its catalog (see GET /actions).
+func (m *Manager) Actions() *ActionRegistry {
+	return m.actions
+}
+
+// RegisterWebhook subscribes a URL to job lifecycle events.
+func (m *Manager) RegisterWebhook(url string) (*webhooks.Endpoint, error) {
+	return m.webhookDispatcher.RegisterEndpoint(url)
+}
+
+// RemoveWebhook unsubscribes a previously registered webhook endpoint.
+func (m *Manager) RemoveWebhook(id string) error {
+	return m.webhookDispatcher.RemoveEndpoint(id)
+}
+
+// WebhookDeliveries returns the delivery history for a registered endpoint.
+func (m *Manager) WebhookDeliveries(id string) ([]*webhooks.Delivery, error) {
+	return m.webhookDispatcher.Deliveries(id)
+}
+
+// emitEvent fans the job's current state out to every registered webhook
+// endpoint as the given lifecycle event.
+func (m *Manager) emitEvent(event webhooks.Event, job *Job) {
+	m.jobsMutex.RLock()
+	envelope := webhooks.EventEnvelope{
+		Event:       event,
+		JobID:       job.ID,
+		Status:      string(job.Status),
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		Output:      job.Output,
+		Error:       job.Error,
+		ExitCode:    job.ExitCode,
+		Metadata:    job.Metadata,
+	}
+	m.jobsMutex.RUnlock()
+
+	m.webhookDispatcher.Dispatch(envelope)
 }
 
 // GetJob retrieves a job by ID
@@ -134,6 +703,7 @@ func (m *Manager) CancelJob(id string) error {
 	job.Status = JobStatusCancelled
 	now := time.Now()
 	job.CompletedAt = &now
+	m.persistLocked(job)
 	m.jobsMutex.Unlock()
 
 	// Cancel the running command if it exists
@@ -157,19 +727,154 @@ func (m *Manager) CancelJob(id string) error {
 		}
 		go m.webhookSender.Send(job.WebhookURL, update)
 	}
+	job.AppendLog(LogStreamSystem, "job cancelled")
+	job.Broker().publishStatus(JobStatusCancelled)
+	m.emitEvent(webhooks.EventJobCancelled, job)
+	m.markWebhookDelivered(job)
+	m.forgetIdempotencyKey(job)
 
 	return nil
 }
 
-// executeJob executes a job
-func (m *Manager) executeJob(job *Job) {
-	// Acquire semaphore to limit concurrent jobs
-	select {
-	case m.semaphore <- struct{}{}:
-		defer func() { <-m.semaphore }()
-	case <-m.ctx.Done():
+// RestartJob re-runs a previously terminal job as a brand new job: it clones
+// Command, Args, Metadata, WebhookURL and TTL into a new Job with a fresh
+// ID, enqueued through the normal CreateJob path, and links the two via a
+// symmetric Metadata["restart_of"]/["restarted_as"] pair. It is an error to
+// restart a job that's still pending or running.
+//
+// The clone carries old.Command/old.Args directly rather than old.Action,
+// since the original request's Params aren't persisted and so can't be
+// re-resolved; the restarted job is still tagged with old.Action afterward
+// so its per-action timeout and concurrency cap still apply.
+func (m *Manager) RestartJob(id string, opts RestartOptions) (*Job, error) {
+	m.jobsMutex.RLock()
+	old, exists := m.jobs[id]
+	m.jobsMutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("job not found: %s", id)
+	}
+	if !old.IsComplete() {
+		return nil, fmt.Errorf("job %s is still %s: only failed, cancelled, or completed jobs can be restarted", id, old.Status)
+	}
+
+	metadata := make(map[string]interface{}, len(old.Metadata)+1)
+	for k, v := range old.Metadata {
+		metadata[k] = v
+	}
+	for k, v := range opts.Metadata {
+		metadata[k] = v
+	}
+	metadata["restart_of"] = old.ID
+
+	ttl := old.TTL
+	job, _, err := m.CreateJob(&JobRequest{
+		Command:     old.Command,
+		Args:        append([]string(nil), old.Args...),
+		Metadata:    metadata,
+		WebhookURL:  old.WebhookURL,
+		TTL:         &ttl,
+		RetryPolicy: old.RetryPolicy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if old.Action != "" {
+		m.jobsMutex.Lock()
+		job.Action = old.Action
+		m.persistLocked(job)
+		m.jobsMutex.Unlock()
+	}
+
+	m.jobsMutex.Lock()
+	if old.Metadata == nil {
+		old.Metadata = make(map[string]interface{})
+	}
+	old.Metadata["restarted_as"] = job.ID
+	m.persistLocked(old)
+	m.jobsMutex.Unlock()
+
+	return job, nil
+}
+
+// forgetIdempotencyKey removes a terminal job's idempotency key from the
+// active-jobs index, so a future request reusing that key starts a fresh job
+// instead of being folded into this one.
+func (m *Manager) forgetIdempotencyKey(job *Job) {
+	if job.IdempotencyKey == "" {
 		return
 	}
+	m.createMutex.Lock()
+	if m.idempotencyKeys[job.IdempotencyKey] == job.ID {
+		delete(m.idempotencyKeys, job.IdempotencyKey)
+	}
+	m.createMutex.Unlock()
+}
+
+// actionFor returns the Action a job was created from, if it has one
+// registered.
+func (m *Manager) actionFor(job *Job) (*Action, bool) {
+	if job.Action == "" {
+		return nil, false
+	}
+	return m.actions.Get(job.Action)
+}
+
+// recordDuration folds d into the manager's moving average job duration
+// (alpha = 0.2), used to estimate queue wait time in QueueStats.
+func (m *Manager) recordDuration(d time.Duration) {
+	m.durationMu.Lock()
+	defer m.durationMu.Unlock()
+	if m.avgDuration == 0 {
+		m.avgDuration = d
+		return
+	}
+	m.avgDuration = m.avgDuration + (d-m.avgDuration)/5
+}
+
+// QueueStats returns the scheduler's current queue depth, in-flight counts,
+// and an estimated wait time for a job submitted right now, for GET
+// /jobs/queue.
+func (m *Manager) QueueStats() QueueStats {
+	stats := m.scheduler.Stats()
+
+	m.durationMu.Lock()
+	avg := m.avgDuration
+	m.durationMu.Unlock()
+
+	maxConcurrent := m.config.Jobs.MaxConcurrent
+	if maxConcurrent <= 0 {
+		maxConcurrent = 1
+	}
+	rounds := (stats.Depth + maxConcurrent - 1) / maxConcurrent
+	stats.EstimatedWait = time.Duration(rounds) * avg
+
+	return stats
+}
+
+// dispatchJob is the scheduler's dispatch callback: it's invoked (in its own
+// goroutine) once job is admitted to run, and releases the job's scheduler
+// slot and jobsWG accounting once it finishes, regardless of how it ends.
+func (m *Manager) dispatchJob(job *Job) {
+	defer m.jobsWG.Done()
+	defer m.scheduler.Release(job)
+	m.runJob(job)
+}
+
+// runJob executes a job that the scheduler has already admitted to run.
+func (m *Manager) runJob(job *Job) {
+	started := time.Now()
+	defer func() { m.recordDuration(time.Since(started)) }()
+
+	// Acquire the job's action-specific concurrency slot, if it has one.
+	if sem, ok := m.actionSemaphores[job.Action]; ok {
+		select {
+		case sem <- struct{}{}:
+			defer func() { <-sem }()
+		case <-m.ctx.Done():
+			return
+		}
+	}
 
 	// Update job status to running
 	m.jobsMutex.Lock()
@@ -180,7 +885,11 @@ func (m *Manager) executeJob(job *Job) {
 	job.Status = JobStatusRunning
 	now := time.Now()
 	job.StartedAt = &now
+	m.persistLocked(job)
 	m.jobsMutex.Unlock()
+	m.markDebounceRunning(job)
+	job.AppendLog(LogStreamSystem, "job started")
+	job.Broker().publishStatus(JobStatusRunning)
 
 	// Send webhook notification for job start
 	if job.WebhookURL != "" {
@@ -195,18 +904,68 @@ func (m *Manager) executeJob(job *Job) {
 		}
 		go m.webhookSender.Send(job.WebhookURL, update)
 	}
+	m.emitEvent(webhooks.EventJobRunning, job)
 
-	// Create a context for this job execution that can be cancelled
+	// Create a context for this job execution that can be cancelled, bounded
+	// by the job's action's Timeout, if it has one.
 	jobCtx, cancelFn := context.WithCancel(m.ctx)
 	defer cancelFn()
+	if action, ok := m.actionFor(job); ok && action.Timeout > 0 {
+		var timeoutCancel context.CancelFunc
+		jobCtx, timeoutCancel = context.WithTimeout(jobCtx, action.Timeout)
+		defer timeoutCancel()
+	}
 
 	// Track running command cancellation function
 	m.runningMutex.Lock()
 	m.running[job.ID] = cancelFn
 	m.runningMutex.Unlock()
 
-	// Execute command using CLI executor
-	result, err := m.executor.Execute(jobCtx, job.Command, job.Args)
+	// Execute command using CLI executor, relaying each line it streams back
+	// to the job's log buffer (and from there to any live /stream or /ws
+	// subscribers) as it arrives rather than waiting for it to finish. On a
+	// retryable failure (see shouldRetry), the job is re-run in place after
+	// a backoff rather than being reported as terminal.
+	var result *executor.ExecuteResult
+	var err error
+	for attempt := job.Attempt; ; attempt++ {
+		chunks := make(chan executor.Chunk, 64)
+		relayDone := make(chan struct{})
+		go func() {
+			defer close(relayDone)
+			for c := range chunks {
+				stream := LogStreamStdout
+				if c.Stream == executor.ChunkStderr {
+					stream = LogStreamStderr
+				}
+				job.AppendLog(stream, c.Line)
+			}
+		}()
+
+		result, err = m.executor.Execute(jobCtx, job.Command, job.Args, chunks)
+		close(chunks)
+		<-relayDone
+
+		if !m.shouldRetry(job, result, err, attempt) {
+			break
+		}
+
+		backoff := retryBackoff(job.RetryPolicy, attempt)
+		job.AppendLog(LogStreamSystem, fmt.Sprintf("attempt %d failed, retrying in %s", attempt, backoff))
+		select {
+		case <-time.After(backoff):
+		case <-jobCtx.Done():
+		}
+
+		m.jobsMutex.Lock()
+		job.Attempt = attempt + 1
+		m.persistLocked(job)
+		m.jobsMutex.Unlock()
+
+		if jobCtx.Err() != nil {
+			break
+		}
+	}
 
 	// Clean up running command tracking
 	m.runningMutex.Lock()
@@ -246,6 +1005,7 @@ func (m *Manager) executeJob(job *Job) {
 		exitCode := 0
 		job.ExitCode = &exitCode
 	}
+	m.persistLocked(job)
 	m.jobsMutex.Unlock()
 
 	// Send final webhook notification
@@ -261,6 +1021,56 @@ func (m *Manager) executeJob(job *Job) {
 		}
 		go m.webhookSender.Send(job.WebhookURL, update)
 	}
+
+	job.AppendLog(LogStreamSystem, fmt.Sprintf("job %s", job.Status))
+	job.Broker().publishDone(job.Status, job.ExitCode)
+
+	terminalEvent := webhooks.EventJobCompleted
+	if job.Status == JobStatusFailed {
+		terminalEvent = webhooks.EventJobFailed
+	}
+	m.emitEvent(terminalEvent, job)
+	m.markWebhookDelivered(job)
+	m.forgetIdempotencyKey(job)
+	m.promoteDebounceNext(job)
+}
+
+// markDebounceRunning records job as the active occupant of its debounce
+// key, if it debounces at all, so a duplicate request arriving while it runs
+// is folded into a "next" job instead of running alongside it.
+func (m *Manager) markDebounceRunning(job *Job) {
+	if !m.shouldDebounce(job.Command) {
+		return
+	}
+	key := newDebounceKey(job.Command, job.Args, job.Metadata)
+	m.createMutex.Lock()
+	m.debounceRunning[key] = job
+	m.createMutex.Unlock()
+}
+
+// promoteDebounceNext clears job's debounce key once it's done running and,
+// if a "next" job was queued behind it, starts that job immediately.
+func (m *Manager) promoteDebounceNext(job *Job) {
+	if !m.shouldDebounce(job.Command) {
+		return
+	}
+	key := newDebounceKey(job.Command, job.Args, job.Metadata)
+
+	m.createMutex.Lock()
+	if m.debounceRunning[key] == job {
+		delete(m.debounceRunning, key)
+	}
+	next, ok := m.debounceNext[key]
+	if ok {
+		delete(m.debounceNext, key)
+	}
+	m.createMutex.Unlock()
+
+	if ok {
+		next.AppendLog(LogStreamSystem, "starting queued job now that the previous run finished")
+		m.jobsWG.Add(1)
+		m.scheduler.Submit(next)
+	}
 }
 
 // validateCommand validates that a command is allowed
@@ -303,39 +1113,37 @@ func (m *Manager) cleanupExpiredJobs() {
 	defer m.jobsMutex.Unlock()
 
 	now := time.Now()
-	toDelete := []string{}
+	toDelete := make(map[string]bool)
 
 	for id, job := range m.jobs {
 		if job.CreatedAt.Add(job.TTL).Before(now) {
-			toDelete = append(toDelete, id)
+			toDelete[id] = true
 		}
 	}
 
-	// Keep history size manageable
-	if len(m.jobs) > m.config.Jobs.MaxHistorySize {
-		// Sort jobs by creation time and remove oldest
-		var sortedJobs []*Job
-		for _, job := range m.jobs {
-			sortedJobs = append(sortedJobs, job)
-		}
-
-		// Simple bubble sort by creation time (oldest first)
-		for i := 0; i < len(sortedJobs)-1; i++ {
-			for j := 0; j < len(sortedJobs)-i-1; j++ {
-				if sortedJobs[j].CreatedAt.After(sortedJobs[j+1].CreatedAt) {
-					sortedJobs[j], sortedJobs[j+1] = sortedJobs[j+1], sortedJobs[j]
-				}
-			}
-		}
-
-		excessCount := len(m.jobs) - m.config.Jobs.MaxHistorySize
-		for i := 0; i < excessCount; i++ {
-			toDelete = append(toDelete, sortedJobs[i].ID)
+	// Keep history size manageable by evicting the oldest jobs via
+	// m.ageQueue, a min-heap by CreatedAt: O(log n) per removal instead of
+	// resorting every job in m.jobs by creation time.
+	remaining := len(m.jobs) - len(toDelete)
+	for remaining > m.config.Jobs.MaxHistorySize && m.ageQueue.Len() > 0 {
+		oldest := heap.Pop(&m.ageQueue).(*ageEntry)
+		delete(m.ageIndex, oldest.id)
+		if toDelete[oldest.id] {
+			// Already counted against TTL expiry above.
+			continue
 		}
+		toDelete[oldest.id] = true
+		remaining--
 	}
 
-	for _, id := range toDelete {
+	for id := range toDelete {
 		delete(m.jobs, id)
+		m.untrackAgeLocked(id)
+		if m.store != nil {
+			if err := m.store.Delete(id); err != nil {
+				log.Printf("job %s: failed to delete from job store: %v", id, err)
+			}
+		}
 	}
 
 	if len(toDelete) > 0 {
@@ -343,23 +1151,61 @@ func (m *Manager) cleanupExpiredJobs() {
 	}
 }
 
-// Shutdown gracefully shuts down the manager
-func (m *Manager) Shutdown() {
-	m.cancel()
+// Shutdown marks the manager as draining, so CreateJob starts rejecting new
+// work, then blocks until every running job finishes or ctx is done,
+// whichever comes first. If ctx expires first, any jobs still running are
+// force-cancelled.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&m.draining, 1)
+
+	drained := make(chan struct{})
+	go func() {
+		m.jobsWG.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		m.cancel()
+		m.closeStore()
+		return nil
+	case <-ctx.Done():
+		n := m.forceCancelRunning()
+		m.cancel()
+		m.closeStore()
+		return fmt.Errorf("shutdown timed out waiting for %d job(s) to drain", n)
+	}
+}
 
-	// Cancel all running jobs
+// closeStore releases the job store's underlying resources, if any.
+func (m *Manager) closeStore() {
+	if m.store == nil {
+		return
+	}
+	if err := m.store.Close(); err != nil {
+		log.Printf("failed to close job store: %v", err)
+	}
+}
+
+// forceCancelRunning cancels every still-running job's context and marks it
+// cancelled, returning how many jobs were force-cancelled. Used when the
+// shutdown drain deadline is exceeded.
+func (m *Manager) forceCancelRunning() int {
 	m.runningMutex.Lock()
+	defer m.runningMutex.Unlock()
+
 	for id, cancelFn := range m.running {
 		cancelFn()
 
-		// Update job status
 		m.jobsMutex.Lock()
 		if job, exists := m.jobs[id]; exists {
 			job.Status = JobStatusCancelled
 			now := time.Now()
 			job.CompletedAt = &now
+			m.persistLocked(job)
 		}
 		m.jobsMutex.Unlock()
 	}
-	m.runningMutex.Unlock()
+
+	return len(m.running)
 }
@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"plandex-cli-api/config"
+)
+
+// TestCreateJob_ResolvesActionIntoCommand asserts a request naming a
+// registered action resolves into the action's underlying Command/Args
+// rather than requiring the caller to know them directly.
+func TestCreateJob_ResolvesActionIntoCommand(t *testing.T) {
+	cfg := testConfig()
+	cfg.Actions = []config.ActionConfig{
+		{Name: "ask", Command: "tell", ArgTemplate: []string{"{prompt:string,required}"}},
+	}
+
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	job, _, err := m.CreateJob(&JobRequest{Action: "ask", Params: map[string]string{"prompt": "hello"}})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	if job.Command != "tell" || len(job.Args) != 1 || job.Args[0] != "hello" {
+		t.Fatalf("expected resolved command %q args %v, got %q %v", "tell", []string{"hello"}, job.Command, job.Args)
+	}
+}
+
+// TestCreateJob_RequireActionsRejectsRawCommand asserts a raw Command
+// request is rejected once config.Jobs.RequireActions is set.
+func TestCreateJob_RequireActionsRejectsRawCommand(t *testing.T) {
+	cfg := testConfig()
+	cfg.Jobs.RequireActions = true
+
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	if _, _, err := m.CreateJob(&JobRequest{Command: "ls"}); err == nil {
+		t.Fatal("expected raw command request to be rejected when RequireActions is set")
+	}
+}
+
+func testConfig() *config.Config {
+	return &config.Config{
+		CLI: config.CLIConfig{WorkingDir: "."},
+		Jobs: config.JobsConfig{
+			MaxConcurrent:   5,
+			DefaultTTL:      time.Hour,
+			CleanupInterval: time.Hour,
+			MaxHistorySize:  100,
+			LogBufferLines:  100,
+			DebounceWindow:  50 * time.Millisecond,
+		},
+	}
+}
+
+// TestCreateJob_IdempotencyKeyDeduplicates fires N concurrent requests with
+// the same idempotency key and asserts they all resolve to a single job.
+func TestCreateJob_IdempotencyKeyDeduplicates(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	const n = 10
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			job, _, err := m.CreateJob(&JobRequest{
+				Command:        "ls",
+				IdempotencyKey: "same-key",
+			})
+			if err != nil {
+				t.Errorf("CreateJob: %v", err)
+				return
+			}
+			ids[i] = job.ID
+		}(i)
+	}
+	wg.Wait()
+
+	first := ids[0]
+	for _, id := range ids {
+		if id != first {
+			t.Fatalf("expected all requests to resolve to job %s, got %s", first, id)
+		}
+	}
+
+	m.jobsMutex.RLock()
+	count := len(m.jobs)
+	m.jobsMutex.RUnlock()
+	if count != 1 {
+		t.Fatalf("expected exactly one job to exist, got %d", count)
+	}
+}
+
+// TestCreateJob_DebounceCoalescesBurst fires N concurrent requests with the
+// same command, args and plan within the debounce window and asserts
+// exactly one job is created.
+func TestCreateJob_DebounceCoalescesBurst(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	const n = 10
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func() {
+			defer wg.Done()
+			if _, _, err := m.CreateJob(&JobRequest{Command: "ls"}); err != nil {
+				t.Errorf("CreateJob: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	m.jobsMutex.RLock()
+	count := len(m.jobs)
+	m.jobsMutex.RUnlock()
+	if count != 1 {
+		t.Fatalf("expected debounce to coalesce burst into one job, got %d", count)
+	}
+}
+
+// TestNewManager_RehydratesInterruptedJobs seeds a store with a job still
+// marked running, as if the process had crashed mid-execution, and asserts a
+// fresh Manager rehydrates it as failed rather than leaving it stuck.
+func TestNewManager_RehydratesInterruptedJobs(t *testing.T) {
+	cfg := testConfig()
+	cfg.Jobs.StorePath = t.TempDir() + "/jobs.db"
+
+	store, err := NewStore(cfg)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	startedAt := time.Now().Add(-time.Minute)
+	if err := store.Save(&JobRecord{
+		ID:        "interrupted-job",
+		Command:   "tell",
+		Status:    JobStatusRunning,
+		CreatedAt: startedAt,
+		StartedAt: &startedAt,
+	}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	job, err := m.GetJob("interrupted-job")
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if job.Status != JobStatusFailed {
+		t.Fatalf("expected rehydrated job to be marked failed, got %s", job.Status)
+	}
+	if job.Error != "interrupted by restart" {
+		t.Fatalf("expected interrupted-by-restart error, got %q", job.Error)
+	}
+}
+
+// waitForTerminal polls until job reaches a terminal status or t fails.
+func waitForTerminal(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := m.GetJob(id)
+		if err != nil {
+			t.Fatalf("GetJob: %v", err)
+		}
+		if job.IsComplete() {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s never reached a terminal state", id)
+	return nil
+}
+
+// TestRestartJob_LinksParentAndChild restarts a terminal job and asserts the
+// new job clones its command/args and that the two are linked via
+// restart_of/restarted_as metadata.
+func TestRestartJob_LinksParentAndChild(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	original, _, err := m.CreateJob(&JobRequest{Command: "ls", Args: []string{"-la"}})
+	if err != nil {
+		t.Fatalf("CreateJob: %v", err)
+	}
+	waitForTerminal(t, m, original.ID)
+
+	restarted, err := m.RestartJob(original.ID, RestartOptions{})
+	if err != nil {
+		t.Fatalf("RestartJob: %v", err)
+	}
+	if restarted.Command != "ls" || len(restarted.Args) != 1 || restarted.Args[0] != "-la" {
+		t.Fatalf("expected restarted job to clone command/args, got %+v", restarted)
+	}
+	if restarted.Metadata["restart_of"] != original.ID {
+		t.Fatalf("expected restarted job's metadata to link back to %s, got %v", original.ID, restarted.Metadata)
+	}
+
+	parent, err := m.GetJob(original.ID)
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if parent.Metadata["restarted_as"] != restarted.ID {
+		t.Fatalf("expected original job's metadata to link to %s, got %v", restarted.ID, parent.Metadata)
+	}
+}
+
+// TestRestartJob_RejectsNonTerminalJob asserts a pending/running job can't
+// be restarted.
+func TestRestartJob_RejectsNonTerminalJob(t *testing.T) {
+	m, err := NewManager(testConfig())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	defer m.cancel()
+
+	m.jobsMutex.Lock()
+	m.jobs["still-running"] = &Job{ID: "still-running", Status: JobStatusRunning, logs: newLogBuffer(10)}
+	m.jobsMutex.Unlock()
+
+	if _, err := m.RestartJob("still-running", RestartOptions{}); err == nil {
+		t.Fatal("expected restarting a running job to be rejected")
+	}
+}
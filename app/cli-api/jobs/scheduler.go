@@ -0,0 +1,226 @@
+package jobs
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// schedEntry is one job waiting in the scheduler's priority queue.
+type schedEntry struct {
+	job   *Job
+	index int
+}
+
+// schedQueue is a container/heap priority queue ordered by (Priority desc,
+// CreatedAt asc): a higher Priority runs first, and ties break FIFO by
+// creation time, so a burst of same-priority jobs still runs in submission
+// order.
+type schedQueue []*schedEntry
+
+func (q schedQueue) Len() int { return len(q) }
+
+func (q schedQueue) Less(i, j int) bool {
+	if q[i].job.Priority != q[j].job.Priority {
+		return q[i].job.Priority > q[j].job.Priority
+	}
+	return q[i].job.CreatedAt.Before(q[j].job.CreatedAt)
+}
+
+func (q schedQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *schedQueue) Push(x interface{}) {
+	entry := x.(*schedEntry)
+	entry.index = len(*q)
+	*q = append(*q, entry)
+}
+
+func (q *schedQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	entry := old[n-1]
+	old[n-1] = nil
+	entry.index = -1
+	*q = old[:n-1]
+	return entry
+}
+
+// QueueStats summarizes a scheduler's current state, for GET /jobs/queue.
+type QueueStats struct {
+	// Depth is the number of jobs waiting to be admitted.
+	Depth int `json:"depth"`
+
+	// Running is the number of jobs currently admitted and executing.
+	Running int `json:"running"`
+
+	// TenantRunning is the number of currently-running jobs per tenant, for
+	// tenants with at least one job in flight.
+	TenantRunning map[string]int `json:"tenant_running,omitempty"`
+
+	// EstimatedWait is a rough estimate of how long a job submitted right
+	// now would wait before starting, derived from the queue depth, the
+	// global concurrency cap, and a moving average of recent job durations.
+	// It's an estimate, not a guarantee - actual wait depends on the mix of
+	// priorities and per-tenant caps among jobs ahead of it.
+	EstimatedWait time.Duration `json:"estimated_wait"`
+}
+
+// scheduler admits queued jobs for execution in priority order, subject to
+// a global concurrency cap and a per-tenant cap: a burst of high-priority
+// jobs from one tenant can't starve every other tenant out of its share of
+// the global cap.
+type scheduler struct {
+	mu            sync.Mutex
+	queue         schedQueue
+	running       int
+	tenantRunning map[string]int
+
+	maxConcurrent          int
+	maxConcurrentPerTenant int
+
+	wake     chan struct{}
+	dispatch func(*Job)
+}
+
+// newScheduler creates a scheduler that calls dispatch (in its own
+// goroutine) once a job is admitted to run. maxConcurrentPerTenant of 0
+// means no per-tenant cap beyond maxConcurrent.
+func newScheduler(maxConcurrent, maxConcurrentPerTenant int, dispatch func(*Job)) *scheduler {
+	return &scheduler{
+		tenantRunning:          make(map[string]int),
+		maxConcurrent:          maxConcurrent,
+		maxConcurrentPerTenant: maxConcurrentPerTenant,
+		wake:                   make(chan struct{}, 1),
+		dispatch:               dispatch,
+	}
+}
+
+// Submit enqueues job to wait its turn.
+func (s *scheduler) Submit(job *Job) {
+	s.mu.Lock()
+	heap.Push(&s.queue, &schedEntry{job: job})
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Release marks a previously-admitted job as finished, freeing its
+// concurrency slot and waking the scheduler to consider the next candidate.
+func (s *scheduler) Release(job *Job) {
+	s.mu.Lock()
+	s.running--
+	if job.Tenant != "" {
+		s.tenantRunning[job.Tenant]--
+		if s.tenantRunning[job.Tenant] <= 0 {
+			delete(s.tenantRunning, job.Tenant)
+		}
+	}
+	s.mu.Unlock()
+	s.poke()
+}
+
+func (s *scheduler) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run drains admissible jobs from the queue until ctx is done. It's meant to
+// run for the lifetime of the manager in its own goroutine.
+func (s *scheduler) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			s.drainRemaining()
+			return
+		case <-s.wake:
+		}
+
+		for {
+			job, ok := s.admitNext()
+			if !ok {
+				break
+			}
+			go s.dispatch(job)
+		}
+	}
+}
+
+// drainRemaining dispatches every job still waiting in the queue once the
+// scheduler is shutting down, bypassing the concurrency caps, so each job's
+// own goroutine observes ctx cancellation directly (and unwinds quickly)
+// instead of sitting queued forever with nothing to ever release it.
+func (s *scheduler) drainRemaining() {
+	s.mu.Lock()
+	jobs := make([]*Job, 0, s.queue.Len())
+	for s.queue.Len() > 0 {
+		entry := heap.Pop(&s.queue).(*schedEntry)
+		jobs = append(jobs, entry.job)
+	}
+	s.mu.Unlock()
+
+	for _, job := range jobs {
+		go s.dispatch(job)
+	}
+}
+
+// admitNext pops and admits the highest-priority job whose tenant still has
+// free capacity, putting aside (and re-queuing afterward) any job whose
+// tenant is already at its per-tenant cap so a lower-priority job for a
+// different tenant can still be admitted instead of head-of-line blocking
+// behind it.
+func (s *scheduler) admitNext() (*Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxConcurrent > 0 && s.running >= s.maxConcurrent {
+		return nil, false
+	}
+
+	var skipped []*schedEntry
+	defer func() {
+		for _, entry := range skipped {
+			heap.Push(&s.queue, entry)
+		}
+	}()
+
+	for s.queue.Len() > 0 {
+		entry := heap.Pop(&s.queue).(*schedEntry)
+		job := entry.job
+
+		if s.maxConcurrentPerTenant > 0 && job.Tenant != "" && s.tenantRunning[job.Tenant] >= s.maxConcurrentPerTenant {
+			skipped = append(skipped, entry)
+			continue
+		}
+
+		s.running++
+		if job.Tenant != "" {
+			s.tenantRunning[job.Tenant]++
+		}
+		return job, true
+	}
+
+	return nil, false
+}
+
+// Stats reports the scheduler's current queue depth, in-flight count, and
+// per-tenant in-flight counts.
+func (s *scheduler) Stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tenantRunning := make(map[string]int, len(s.tenantRunning))
+	for k, v := range s.tenantRunning {
+		tenantRunning[k] = v
+	}
+	return QueueStats{
+		Depth:         s.queue.Len(),
+		Running:       s.running,
+		TenantRunning: tenantRunning,
+	}
+}
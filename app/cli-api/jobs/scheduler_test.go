@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestScheduler_PriorityOrdersAdmission asserts a higher-priority job is
+// admitted ahead of a lower-priority one submitted first, once capacity
+// frees up.
+func TestScheduler_PriorityOrdersAdmission(t *testing.T) {
+	admitted := make(chan *Job, 2)
+	s := newScheduler(1, 0, func(job *Job) { admitted <- job })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.run(ctx)
+
+	low := &Job{ID: "low", Priority: 0, CreatedAt: time.Now()}
+	s.Submit(low)
+
+	first := <-admitted
+	if first.ID != "low" {
+		t.Fatalf("expected the only queued job to be admitted first, got %s", first.ID)
+	}
+
+	high := &Job{ID: "high", Priority: 10, CreatedAt: time.Now()}
+	normal := &Job{ID: "normal", Priority: 0, CreatedAt: time.Now()}
+	s.Submit(normal)
+	s.Submit(high)
+
+	// Release the running slot so the next-highest-priority job is admitted.
+	s.Release(low)
+
+	second := <-admitted
+	if second.ID != "high" {
+		t.Fatalf("expected the higher-priority job to be admitted next, got %s", second.ID)
+	}
+}
+
+// TestScheduler_PerTenantCapSkipsOverCappedTenant asserts a job from a
+// tenant already at its per-tenant cap doesn't block a job from a different
+// tenant behind it in the queue.
+func TestScheduler_PerTenantCapSkipsOverCappedTenant(t *testing.T) {
+	admitted := make(chan *Job, 2)
+	s := newScheduler(2, 1, func(job *Job) { admitted <- job })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.run(ctx)
+
+	first := &Job{ID: "a1", Tenant: "a", Priority: 0, CreatedAt: time.Now()}
+	s.Submit(first)
+	if got := <-admitted; got.ID != "a1" {
+		t.Fatalf("expected a1 to be admitted, got %s", got.ID)
+	}
+
+	// A second job for tenant "a" should be held back by the per-tenant cap,
+	// while a job for tenant "b" behind it in the queue is still admitted.
+	second := &Job{ID: "a2", Tenant: "a", Priority: 0, CreatedAt: time.Now()}
+	third := &Job{ID: "b1", Tenant: "b", Priority: 0, CreatedAt: time.Now().Add(time.Millisecond)}
+	s.Submit(second)
+	s.Submit(third)
+
+	got := <-admitted
+	if got.ID != "b1" {
+		t.Fatalf("expected tenant b's job to be admitted around tenant a's cap, got %s", got.ID)
+	}
+}
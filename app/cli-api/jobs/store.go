@@ -0,0 +1,307 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"plandex-cli-api/config"
+	"plandex-cli-api/webhooks"
+)
+
+// JobRecord is the persisted representation of a Job: everything needed to
+// rehydrate it, and account for its terminal webhook delivery, after a
+// restart.
+type JobRecord struct {
+	ID             string
+	Command        string
+	Args           []string
+	Status         JobStatus
+	CreatedAt      time.Time
+	StartedAt      *time.Time
+	CompletedAt    *time.Time
+	Output         string
+	Error          string
+	ExitCode       *int
+	Metadata       map[string]interface{}
+	WebhookURL     string
+	TTL            time.Duration
+	IdempotencyKey string
+	Action         string
+	Priority       int
+	Tenant         string
+	RetryPolicy    *RetryPolicy
+	Attempt        int
+
+	// WebhookDelivered is set once the job's terminal webhook event
+	// (completed/failed/cancelled) has been handed to the dispatcher, so a
+	// restart before that hand-off can tell it still owes one.
+	WebhookDelivered bool
+}
+
+// Store persists JobRecords so jobs and their terminal-webhook-delivery
+// state survive a server restart.
+type Store interface {
+	// Save upserts a job record.
+	Save(rec *JobRecord) error
+	// List returns every persisted job record, in no particular order.
+	List() ([]*JobRecord, error)
+	// Delete removes a record. It is not an error if id doesn't exist.
+	Delete(id string) error
+	// Close releases the store's underlying resources.
+	Close() error
+
+	// SaveWebhookEndpoint upserts a webhook endpoint, persisted alongside
+	// jobs so registrations survive a restart.
+	SaveWebhookEndpoint(ep webhooks.Endpoint) error
+	// DeleteWebhookEndpoint removes a webhook endpoint and its delivery
+	// history. It is not an error if id doesn't exist.
+	DeleteWebhookEndpoint(id string) error
+	// ListWebhookEndpoints returns every persisted webhook endpoint, in no
+	// particular order.
+	ListWebhookEndpoints() ([]webhooks.Endpoint, error)
+	// SaveWebhookDeliveries replaces the persisted delivery history for an
+	// endpoint.
+	SaveWebhookDeliveries(endpointID string, deliveries []*webhooks.Delivery) error
+	// ListWebhookDeliveries returns the persisted delivery history for every
+	// endpoint, keyed by endpoint ID.
+	ListWebhookDeliveries() (map[string][]*webhooks.Delivery, error)
+}
+
+// NewStore opens the Store selected by cfg.Jobs.StorePath: a BoltDB file at
+// that path, or an in-memory store (the same one tests use) if it's empty.
+func NewStore(cfg *config.Config) (Store, error) {
+	if cfg.Jobs.StorePath == "" {
+		return newMemStore(), nil
+	}
+	return newBoltStore(cfg.Jobs.StorePath)
+}
+
+// memStore is an in-memory Store. It's what NewStore returns when no
+// StorePath is configured, so jobs still round-trip through the Store
+// interface in tests without requiring a file on disk.
+type memStore struct {
+	mu      sync.RWMutex
+	records map[string]*JobRecord
+
+	webhookMu         sync.RWMutex
+	webhookEndpoints  map[string]webhooks.Endpoint
+	webhookDeliveries map[string][]*webhooks.Delivery
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		records:           make(map[string]*JobRecord),
+		webhookEndpoints:  make(map[string]webhooks.Endpoint),
+		webhookDeliveries: make(map[string][]*webhooks.Delivery),
+	}
+}
+
+func (s *memStore) Save(rec *JobRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *rec
+	s.records[rec.ID] = &cp
+	return nil
+}
+
+func (s *memStore) List() ([]*JobRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]*JobRecord, 0, len(s.records))
+	for _, rec := range s.records {
+		cp := *rec
+		out = append(out, &cp)
+	}
+	return out, nil
+}
+
+func (s *memStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, id)
+	return nil
+}
+
+func (s *memStore) Close() error { return nil }
+
+func (s *memStore) SaveWebhookEndpoint(ep webhooks.Endpoint) error {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	s.webhookEndpoints[ep.ID] = ep
+	return nil
+}
+
+func (s *memStore) DeleteWebhookEndpoint(id string) error {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	delete(s.webhookEndpoints, id)
+	delete(s.webhookDeliveries, id)
+	return nil
+}
+
+func (s *memStore) ListWebhookEndpoints() ([]webhooks.Endpoint, error) {
+	s.webhookMu.RLock()
+	defer s.webhookMu.RUnlock()
+	out := make([]webhooks.Endpoint, 0, len(s.webhookEndpoints))
+	for _, ep := range s.webhookEndpoints {
+		out = append(out, ep)
+	}
+	return out, nil
+}
+
+func (s *memStore) SaveWebhookDeliveries(endpointID string, deliveries []*webhooks.Delivery) error {
+	s.webhookMu.Lock()
+	defer s.webhookMu.Unlock()
+	s.webhookDeliveries[endpointID] = deliveries
+	return nil
+}
+
+func (s *memStore) ListWebhookDeliveries() (map[string][]*webhooks.Delivery, error) {
+	s.webhookMu.RLock()
+	defer s.webhookMu.RUnlock()
+	out := make(map[string][]*webhooks.Delivery, len(s.webhookDeliveries))
+	for id, dels := range s.webhookDeliveries {
+		out[id] = dels
+	}
+	return out, nil
+}
+
+var (
+	jobsBucket              = []byte("jobs")
+	webhookEndpointsBucket  = []byte("webhook_endpoints")
+	webhookDeliveriesBucket = []byte("webhook_deliveries")
+)
+
+// boltStore persists job records in a BoltDB file, one key-value pair per
+// job (keyed by ID) in a single "jobs" bucket.
+type boltStore struct {
+	db *bbolt.DB
+}
+
+func newBoltStore(path string) (*boltStore, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt job store at %q: %w", path, err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range [][]byte{jobsBucket, webhookEndpointsBucket, webhookDeliveriesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create job store buckets: %w", err)
+	}
+
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Save(rec *JobRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job record: %w", err)
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(rec.ID), data)
+	})
+}
+
+func (s *boltStore) List() ([]*JobRecord, error) {
+	var out []*JobRecord
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var rec JobRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to unmarshal job record %s: %w", k, err)
+			}
+			out = append(out, &rec)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *boltStore) Delete(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete([]byte(id))
+	})
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *boltStore) SaveWebhookEndpoint(ep webhooks.Endpoint) error {
+	data, err := json.Marshal(ep)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook endpoint: %w", err)
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(webhookEndpointsBucket).Put([]byte(ep.ID), data)
+	})
+}
+
+func (s *boltStore) DeleteWebhookEndpoint(id string) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(webhookEndpointsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		return tx.Bucket(webhookDeliveriesBucket).Delete([]byte(id))
+	})
+}
+
+func (s *boltStore) ListWebhookEndpoints() ([]webhooks.Endpoint, error) {
+	var out []webhooks.Endpoint
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(webhookEndpointsBucket).ForEach(func(k, v []byte) error {
+			var ep webhooks.Endpoint
+			if err := json.Unmarshal(v, &ep); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook endpoint %s: %w", k, err)
+			}
+			out = append(out, ep)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (s *boltStore) SaveWebhookDeliveries(endpointID string, deliveries []*webhooks.Delivery) error {
+	data, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook deliveries: %w", err)
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(webhookDeliveriesBucket).Put([]byte(endpointID), data)
+	})
+}
+
+func (s *boltStore) ListWebhookDeliveries() (map[string][]*webhooks.Delivery, error) {
+	out := make(map[string][]*webhooks.Delivery)
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(webhookDeliveriesBucket).ForEach(func(k, v []byte) error {
+			var dels []*webhooks.Delivery
+			if err := json.Unmarshal(v, &dels); err != nil {
+				return fmt.Errorf("failed to unmarshal webhook deliveries for %s: %w", k, err)
+			}
+			out[string(k)] = dels
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"sync"
 	"time"
 )
 
@@ -30,15 +31,100 @@ type Job struct {
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	WebhookURL  string                 `json:"webhook_url,omitempty"`
 	TTL         time.Duration          `json:"ttl"`
+
+	// Priority orders this job in the scheduler's queue: a higher Priority
+	// runs ahead of a lower one; jobs sharing a Priority run FIFO. Defaults
+	// to 0.
+	Priority int `json:"priority"`
+
+	// Tenant, if set, is charged against config.Jobs.MaxConcurrentPerTenant
+	// by the scheduler, so a burst of jobs from one tenant can't starve out
+	// every other tenant's share of the global concurrency cap.
+	Tenant string `json:"tenant,omitempty"`
+
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// Action is the name of the jobs.Action this job was created from, if
+	// any - empty for a job created from a raw Command. Persisted so its
+	// per-action timeout and concurrency cap can still be looked up after a
+	// restart.
+	Action string `json:"action,omitempty"`
+
+	// RetryPolicy, if set, governs automatic retries of this exact job (see
+	// Manager.shouldRetry): it's consulted and re-run in place, with the
+	// final webhook held back until a retry succeeds or attempts run out.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+
+	// Attempt is the 1-based attempt number; it only climbs past 1 for a
+	// job with a RetryPolicy that's been automatically retried.
+	Attempt int `json:"attempt"`
+
+	// WebhookDelivered is set once this job's terminal webhook event has
+	// been handed to the dispatcher, so a restart before that point knows
+	// to re-fire it.
+	WebhookDelivered bool `json:"-"`
+
+	logs *logBuffer
+
+	broker     *Broker
+	brokerOnce sync.Once
 }
 
 // JobRequest represents a request to create a new job
 type JobRequest struct {
 	Command    string                 `json:"command"`
 	Args       []string               `json:"args,omitempty"`
+	WorkingDir string                 `json:"working_dir,omitempty"`
 	Metadata   map[string]interface{} `json:"metadata,omitempty"`
 	WebhookURL string                 `json:"webhook_url,omitempty"`
 	TTL        *time.Duration         `json:"ttl,omitempty"`
+
+	// Priority and Tenant feed the scheduler (see Manager's scheduler):
+	// Priority orders this job's place in the queue, and Tenant is charged
+	// against config.Jobs.MaxConcurrentPerTenant.
+	Priority int    `json:"priority,omitempty"`
+	Tenant   string `json:"tenant,omitempty"`
+
+	// Action, if set, names a registered jobs.Action (see
+	// config.Config.Actions) that CreateJob resolves against Params to
+	// produce Command/Args, instead of requiring the caller to pass them
+	// directly. Command/Args are ignored when Action is set.
+	Action string            `json:"action,omitempty"`
+	Params map[string]string `json:"params,omitempty"`
+
+	// IdempotencyKey, if set, makes job creation idempotent: a request
+	// reusing the key of a still-pending or running job returns that job
+	// instead of starting a duplicate.
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+
+	// RetryPolicy, if set, automatically re-runs the job in place on a
+	// retryable failure instead of firing the final webhook straight away.
+	RetryPolicy *RetryPolicy `json:"retry_policy,omitempty"`
+}
+
+// RetryPolicy configures automatic retries for a job that ends in a
+// retryable failure: the manager re-runs it in place (same job ID, Attempt
+// incremented) after Backoff*2^(attempt-1), up to MaxAttempts total
+// attempts, before giving up and firing the final webhook.
+type RetryPolicy struct {
+	MaxAttempts int           `json:"max_attempts"`
+	Backoff     time.Duration `json:"backoff"`
+
+	// RetryOn lists which terminal statuses are retried. Defaults to just
+	// JobStatusFailed if empty - a cancelled job is never auto-retried.
+	RetryOn []JobStatus `json:"retry_on,omitempty"`
+
+	// RetryableExitCodes, if non-empty, restricts retries to a failed job
+	// whose exit code is in this list. Empty means any non-zero exit code
+	// is retryable.
+	RetryableExitCodes []int `json:"retryable_exit_codes,omitempty"`
+}
+
+// RestartOptions configures Manager.RestartJob.
+type RestartOptions struct {
+	// Metadata, if non-nil, is merged into (overriding on key conflict) the
+	// restarted job's cloned metadata.
+	Metadata map[string]interface{}
 }
 
 // JobResponse represents the response when creating or querying a job
@@ -53,17 +139,19 @@ type JobResponse struct {
 	Error       string                 `json:"error,omitempty"`
 	ExitCode    *int                   `json:"exit_code,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Attempt     int                    `json:"attempt"`
+	Action      string                 `json:"action,omitempty"`
+	Priority    int                    `json:"priority"`
+	Tenant      string                 `json:"tenant,omitempty"`
 }
 
-
-
 // CommandMapping defines how CLI commands map to API endpoints
 type CommandMapping struct {
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	Args        []CommandArg      `json:"args"`
-	Flags       []CommandFlag     `json:"flags"`
-	Examples    []CommandExample  `json:"examples"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	Args        []CommandArg     `json:"args"`
+	Flags       []CommandFlag    `json:"flags"`
+	Examples    []CommandExample `json:"examples"`
 }
 
 // CommandArg represents a command argument
@@ -95,6 +183,30 @@ func (j *Job) IsComplete() bool {
 	return j.Status == JobStatusCompleted || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
 }
 
+// AppendLog records a line of output for this job and fans it out to any
+// live log subscribers.
+func (j *Job) AppendLog(stream LogStream, line string) LogEntry {
+	return j.logs.append(stream, line)
+}
+
+// LogsSince returns every buffered log line with a cursor greater than since,
+// letting a reconnecting client resume a stream without losing output.
+func (j *Job) LogsSince(since int) []LogEntry {
+	return j.logs.since(since)
+}
+
+// SubscribeLogs registers a live tail of this job's log output. The returned
+// cancel func must be called when the subscriber disconnects.
+func (j *Job) SubscribeLogs() (<-chan LogEntry, func()) {
+	return j.logs.subscribe()
+}
+
+// Broker returns this job's Broker, creating it on first use.
+func (j *Job) Broker() *Broker {
+	j.brokerOnce.Do(func() { j.broker = newBroker(j) })
+	return j.broker
+}
+
 // ToResponse converts a Job to a JobResponse
 func (j *Job) ToResponse() *JobResponse {
 	return &JobResponse{
@@ -108,7 +220,9 @@ func (j *Job) ToResponse() *JobResponse {
 		Error:       j.Error,
 		ExitCode:    j.ExitCode,
 		Metadata:    j.Metadata,
+		Attempt:     j.Attempt,
+		Action:      j.Action,
+		Priority:    j.Priority,
+		Tenant:      j.Tenant,
 	}
 }
-
- 
\ No newline at end of file
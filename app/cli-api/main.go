@@ -13,8 +13,19 @@ func main() {
 	var configPath = flag.String("config", "plandex-api.json", "Path to configuration file")
 	var port = flag.Int("port", 8080, "Port to run API server on")
 	var help = flag.Bool("help", false, "Show help message")
+	var generateCert = flag.Bool("generate-cert", false, "Generate a self-signed TLS cert/key pair for local dev and exit")
+	var certOut = flag.String("cert-out", "server.crt", "Path to write the generated certificate (with --generate-cert)")
+	var keyOut = flag.String("key-out", "server.key", "Path to write the generated private key (with --generate-cert)")
 	flag.Parse()
 
+	if *generateCert {
+		if err := server.GenerateSelfSignedCert(*certOut, *keyOut); err != nil {
+			log.Fatalf("Error generating self-signed cert: %v", err)
+		}
+		fmt.Printf("Wrote self-signed cert to %s and key to %s\n", *certOut, *keyOut)
+		return
+	}
+
 	if *help {
 		fmt.Println("Plandex CLI API Wrapper")
 		fmt.Println("Usage: plandex-cli-api [options]")
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"plandex-cli-api/config"
+)
+
+// jwtClaims are the claims plandex-cli-api mints and verifies. Scope is a
+// space-separated list of scope names, following the OAuth2 convention.
+type jwtClaims struct {
+	Scope string `json:"scope,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// authSubjectKey is the context key under which the authenticated subject
+// (API key, JWT sub, or mTLS CommonName) is stamped for loggingMiddleware.
+type authSubjectKey struct{}
+
+// parseAndVerifyJWT validates tokenString's signature (HS256 via
+// Auth.JWTSecret, or RS256 via a key fetched from Auth.JWKSURL), issuer,
+// audience, and expiry, returning its claims.
+func (s *Server) parseAndVerifyJWT(tokenString string) (*jwtClaims, error) {
+	claims := &jwtClaims{}
+
+	parserOpts := []jwt.ParserOption{}
+	if s.config.Auth.JWTIssuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(s.config.Auth.JWTIssuer))
+	}
+	if s.config.Auth.JWTAudience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(s.config.Auth.JWTAudience))
+	}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if s.config.Auth.JWTSecret == "" {
+				return nil, fmt.Errorf("HS256 token presented but no jwt_secret is configured")
+			}
+			return []byte(s.config.Auth.JWTSecret), nil
+		case *jwt.SigningMethodRSA:
+			if s.config.Auth.JWKSURL == "" {
+				return nil, fmt.Errorf("RS256 token presented but no jwks_url is configured")
+			}
+			kid, _ := t.Header["kid"].(string)
+			return s.jwks.key(s.config.Auth.JWKSURL, kid)
+		default:
+			return nil, fmt.Errorf("unsupported signing method: %v", t.Header["alg"])
+		}
+	}, parserOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// scopes splits the space-separated scope claim into individual scopes.
+func (c *jwtClaims) scopes() []string {
+	if c.Scope == "" {
+		return nil
+	}
+	return strings.Fields(c.Scope)
+}
+
+// scopesAuthorize reports whether any of the given scopes authorizes method
+// + path against cfg.Auth.Scopes. If no scopes are configured at all, scope
+// checking is disabled and every authenticated request is authorized.
+func scopesAuthorize(cfg *config.Config, scopes []string, method, path string) bool {
+	if len(cfg.Auth.Scopes) == 0 {
+		return true
+	}
+
+	for _, scope := range scopes {
+		for _, rule := range cfg.Auth.Scopes[scope] {
+			if rule.Method != "*" && !strings.EqualFold(rule.Method, method) {
+				continue
+			}
+			if strings.HasPrefix(path, rule.Prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mintJWT issues a short-lived HS256 token for subject, scoped to every
+// scope configured in cfg.Auth.Scopes, for POST /api/v1/auth/token to
+// exchange a valid API key for.
+func (s *Server) mintJWT(subject string) (string, error) {
+	if s.config.Auth.JWTSecret == "" {
+		return "", fmt.Errorf("jwt_secret is not configured")
+	}
+
+	scopeNames := make([]string, 0, len(s.config.Auth.Scopes))
+	for scope := range s.config.Auth.Scopes {
+		scopeNames = append(scopeNames, scope)
+	}
+
+	now := time.Now()
+	claims := &jwtClaims{
+		Scope: strings.Join(scopeNames, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			Issuer:    s.config.Auth.JWTIssuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.config.Auth.TokenTTL.Duration)),
+		},
+	}
+	if s.config.Auth.JWTAudience != "" {
+		claims.Audience = jwt.ClaimStrings{s.config.Auth.JWTAudience}
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.Auth.JWTSecret))
+}
+
+// mintTokenRequest is the body of POST /api/v1/auth/token.
+type mintTokenRequest struct {
+	APIKey string `json:"api_key"`
+}
+
+// mintTokenResponse is the response of POST /api/v1/auth/token.
+type mintTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// mintToken exchanges a valid API key for a short-lived JWT, so existing
+// X-API-Key integrations can upgrade to scoped bearer tokens gradually.
+func (s *Server) mintToken(w http.ResponseWriter, r *http.Request) {
+	var req mintTokenRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	if req.APIKey == "" {
+		req.APIKey = r.Header.Get("X-API-Key")
+	}
+
+	valid := false
+	for _, key := range s.config.Auth.APIKeys {
+		if key == req.APIKey {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		http.Error(w, "invalid API key", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := s.mintJWT(req.APIKey)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mintTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.config.Auth.TokenTTL.Duration.Seconds()),
+	})
+}
+
+// jwksCache fetches and caches RS256 public keys by "kid" from a JWKS
+// endpoint, refetching the whole set whenever an unknown kid is requested
+// (e.g. after the issuer rotates keys).
+type jwksCache struct {
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+type jwksDocument struct {
+	Keys []jwksKey `json:"keys"`
+}
+
+type jwksKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (c *jwksCache) key(url, kid string) (*rsa.PublicKey, error) {
+	c.mu.Lock()
+	if key, ok := c.keys[kid]; ok {
+		c.mu.Unlock()
+		return key, nil
+	}
+	c.mu.Unlock()
+
+	if err := c.refresh(url); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("no JWKS key found for kid %q", kid)
+	}
+	return key, nil
+}
+
+func (c *jwksCache) refresh(url string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var doc jwksDocument
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := rsaPublicKeyFromJWK(k.N, k.E)
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.mu.Unlock()
+
+	return nil
+}
+
+func rsaPublicKeyFromJWK(nB64, eB64 string) (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(nB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(eB64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
@@ -0,0 +1,59 @@
+//go:build linux
+
+package server
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// dropPrivileges switches the process to the named unprivileged user/group
+// once the listener has been bound, so the server never runs as root longer
+// than it takes to acquire a privileged port. Group is dropped before user
+// since a process that has already dropped its uid typically can't change
+// its gid anymore.
+func dropPrivileges(userName, groupName string) error {
+	if userName == "" && groupName == "" {
+		return nil
+	}
+
+	if groupName != "" {
+		gid, err := lookupGid(groupName)
+		if err != nil {
+			return err
+		}
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("setgid(%d): %w", gid, err)
+		}
+	}
+
+	if userName != "" {
+		u, err := user.Lookup(userName)
+		if err != nil {
+			return fmt.Errorf("lookup user %q: %w", userName, err)
+		}
+		uid, err := strconv.Atoi(u.Uid)
+		if err != nil {
+			return fmt.Errorf("parse uid for %q: %w", userName, err)
+		}
+		if err := syscall.Setuid(uid); err != nil {
+			return fmt.Errorf("setuid(%d): %w", uid, err)
+		}
+	}
+
+	return nil
+}
+
+func lookupGid(groupName string) (int, error) {
+	g, err := user.LookupGroup(groupName)
+	if err != nil {
+		return 0, fmt.Errorf("lookup group %q: %w", groupName, err)
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	if err != nil {
+		return 0, fmt.Errorf("parse gid for %q: %w", groupName, err)
+	}
+	return gid, nil
+}
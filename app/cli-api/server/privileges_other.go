@@ -0,0 +1,14 @@
+//go:build !linux
+
+package server
+
+import "log"
+
+// dropPrivileges is a no-op on platforms other than Linux; Server.User /
+// Server.Group are logged but not enforced.
+func dropPrivileges(userName, groupName string) error {
+	if userName != "" || groupName != "" {
+		log.Printf("server.user/server.group are only enforced on Linux; ignoring on this platform")
+	}
+	return nil
+}
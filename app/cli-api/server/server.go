@@ -1,25 +1,44 @@
 package server
 
 import (
+	"context"
+	"crypto/rsa"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
 
 	"plandex-cli-api/config"
 	"plandex-cli-api/jobs"
 )
 
+// wsUpgrader upgrades log-streaming connections. Origin checking is left to
+// the API key/auth middleware in front of it.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
 // Server represents the HTTP server
 type Server struct {
 	config     *config.Config
 	jobManager *jobs.Manager
 	server     *http.Server
+	jwks       *jwksCache
 }
 
 // New creates a new server instance
@@ -28,25 +47,32 @@ func New(cfg *config.Config) (*Server, error) {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	jobManager := jobs.NewManager(cfg)
+	jobManager, err := jobs.NewManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job manager: %w", err)
+	}
 
 	s := &Server{
 		config:     cfg,
 		jobManager: jobManager,
+		jwks:       &jwksCache{keys: make(map[string]*rsa.PublicKey)},
 	}
 
 	// Setup HTTP server
-	s.setupServer()
+	if err := s.setupServer(); err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
 
 // setupServer configures the HTTP server and routes
-func (s *Server) setupServer() {
+func (s *Server) setupServer() error {
 	router := mux.NewRouter()
 
 	// Add middleware
 	router.Use(s.loggingMiddleware)
+	router.Use(s.clientCertMiddleware)
 	router.Use(s.authMiddleware)
 
 	// API routes
@@ -55,16 +81,32 @@ func (s *Server) setupServer() {
 	// Job management endpoints
 	api.HandleFunc("/jobs", s.createJob).Methods("POST")
 	api.HandleFunc("/jobs", s.listJobs).Methods("GET")
+	api.HandleFunc("/jobs/queue", s.jobQueueStats).Methods("GET")
 	api.HandleFunc("/jobs/{id}", s.getJob).Methods("GET")
 	api.HandleFunc("/jobs/{id}/cancel", s.cancelJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/restart", s.restartJob).Methods("POST")
+	api.HandleFunc("/jobs/{id}/logs/stream", s.streamJobLogsSSE).Methods("GET")
+	api.HandleFunc("/jobs/{id}/logs/ws", s.streamJobLogsWS).Methods("GET")
+	api.HandleFunc("/jobs/{id}/stream", s.streamJobSSE).Methods("GET")
+	api.HandleFunc("/jobs/{id}/ws", s.streamJobWS).Methods("GET")
+
+	// Webhook subscription endpoints
+	api.HandleFunc("/webhooks", s.createWebhook).Methods("POST")
+	api.HandleFunc("/webhooks/{id}", s.deleteWebhook).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id}/deliveries", s.listWebhookDeliveries).Methods("GET")
 
 	// Command documentation endpoint
 	api.HandleFunc("/commands", s.listCommands).Methods("GET")
 	api.HandleFunc("/commands/{command}", s.getCommand).Methods("GET")
 
+	api.HandleFunc("/actions", s.listActions).Methods("GET")
+
 	// Health check
 	api.HandleFunc("/health", s.healthCheck).Methods("GET")
 
+	// Mints a short-lived JWT from a valid API key
+	api.HandleFunc("/auth/token", s.mintToken).Methods("POST")
+
 	// Setup CORS if enabled
 	var handler http.Handler = router
 	if s.config.Security.EnableCORS {
@@ -83,18 +125,76 @@ func (s *Server) setupServer() {
 		WriteTimeout: s.config.Server.WriteTimeout.Duration,
 		IdleTimeout:  s.config.Server.IdleTimeout.Duration,
 	}
+
+	if s.config.Server.TLS.Enabled() {
+		tlsCfg, err := buildTLSConfig(s.config.Server.TLS)
+		if err != nil {
+			return fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		s.server.TLSConfig = tlsCfg
+	}
+
+	return nil
 }
 
-// Start starts the HTTP server
+// Start starts the HTTP(S) server and blocks until it stops, either because
+// serving returned (e.g. a bind error) or because SIGINT/SIGTERM triggered a
+// graceful shutdown. The listener is bound up front so that Server.User /
+// Server.Group can drop privileges immediately afterwards, even when binding
+// a privileged port.
 func (s *Server) Start() error {
-	log.Printf("Server starting on %s", s.server.Addr)
-	return s.server.ListenAndServe()
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to bind %s: %w", s.server.Addr, err)
+	}
+
+	if err := dropPrivileges(s.config.Server.User, s.config.Server.Group); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to drop privileges: %w", err)
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	serveErr := make(chan error, 1)
+	if s.config.Server.TLS.Enabled() {
+		log.Printf("Server starting on %s (TLS)", s.server.Addr)
+		go func() {
+			serveErr <- s.server.ServeTLS(listener, s.config.Server.TLS.CertFile, s.config.Server.TLS.KeyFile)
+		}()
+	} else {
+		log.Printf("Server starting on %s", s.server.Addr)
+		go func() {
+			serveErr <- s.server.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case sig := <-sigCh:
+		log.Printf("Received %s, draining in-flight jobs before shutdown", sig)
+		s.Shutdown()
+		return nil
+	}
 }
 
-// Shutdown gracefully shuts down the server
+// Shutdown gracefully shuts down the server: it stops accepting new jobs
+// immediately (CreateJob starts returning 503) while draining jobs already
+// running, then stops the HTTP server once they finish or
+// Server.ShutdownTimeout elapses, whichever comes first. In-flight GET
+// requests keep being served throughout.
 func (s *Server) Shutdown() {
-	s.jobManager.Shutdown()
-	s.server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout.Duration)
+	defer cancel()
+
+	if err := s.jobManager.Shutdown(ctx); err != nil {
+		log.Printf("Graceful shutdown: %v", err)
+	}
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Printf("HTTP server shutdown error: %v", err)
+	}
 }
 
 // Middleware
@@ -102,15 +202,43 @@ func (s *Server) Shutdown() {
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+
+		subject := new(string)
+		r = r.WithContext(context.WithValue(r.Context(), authSubjectKey{}, subject))
+
 		next.ServeHTTP(w, r)
-		log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
+
+		if *subject != "" {
+			log.Printf("%s %s %v subject=%s", r.Method, r.URL.Path, time.Since(start), *subject)
+		} else {
+			log.Printf("%s %s %v", r.Method, r.URL.Path, time.Since(start))
+		}
+	})
+}
+
+// stampSubject records the authenticated subject for this request so
+// loggingMiddleware can attach it to the access log line.
+func stampSubject(r *http.Request, subject string) {
+	if s, ok := r.Context().Value(authSubjectKey{}).(*string); ok {
+		*s = subject
+	}
+}
+
+// clientCertMiddleware stashes the CommonName of the client certificate
+// verified during the mTLS handshake, if any, into the request context ahead
+// of authMiddleware.
+func (s *Server) clientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(withClientCertCN(r.Context(), r.TLS)))
 	})
 }
 
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health check
-		if r.URL.Path == "/api/v1/health" {
+		// Skip auth for the health check, which is public, and for token
+		// minting, which checks the API key itself (and is how a client gets
+		// credentials in the first place).
+		if r.URL.Path == "/api/v1/health" || r.URL.Path == "/api/v1/auth/token" {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -120,6 +248,35 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// A verified mTLS client certificate is accepted as an alternative
+		// to the API key, authorizing by its CommonName as the subject.
+		if cn, ok := clientCertCN(r.Context()); ok {
+			stampSubject(r, cn)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Bearer JWT, accepted alongside the API key, scoped per
+		// Auth.Scopes.
+		if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+
+			claims, err := s.parseAndVerifyJWT(tokenString)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid token: %v", err), http.StatusUnauthorized)
+				return
+			}
+
+			if !scopesAuthorize(s.config, claims.scopes(), r.Method, r.URL.Path) {
+				http.Error(w, "token does not have a scope covering this route", http.StatusForbidden)
+				return
+			}
+
+			stampSubject(r, claims.Subject)
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		// Check API key
 		apiKey := r.Header.Get("X-API-Key")
 		if apiKey == "" {
@@ -140,6 +297,7 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		stampSubject(r, apiKey)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -152,15 +310,26 @@ func (s *Server) createJob(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if req.IdempotencyKey == "" {
+		req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	}
 
-	job, err := s.jobManager.CreateJob(&req)
+	job, existed, err := s.jobManager.CreateJob(&req)
 	if err != nil {
+		if errors.Is(err, jobs.ErrDraining) {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	if existed {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
 	json.NewEncoder(w).Encode(job.ToResponse())
 }
 
@@ -207,6 +376,14 @@ func (s *Server) listJobs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(responses)
 }
 
+// jobQueueStats returns the scheduler's current queue depth, per-tenant
+// in-flight counts, and an estimated wait time, so operators can see
+// head-of-line blocking before it shows up as client-visible latency.
+func (s *Server) jobQueueStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.jobManager.QueueStats())
+}
+
 func (s *Server) cancelJob(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
@@ -220,6 +397,434 @@ func (s *Server) cancelJob(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Job cancelled"))
 }
 
+func (s *Server) restartJob(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	var opts jobs.RestartOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+
+	job, err := s.jobManager.RestartJob(id, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job.ToResponse())
+}
+
+// streamJobLogsSSE streams a job's log output as Server-Sent Events. It
+// replays buffered lines since ?since=<cursor> and, with ?follow=true, keeps
+// the connection open until the job reaches a terminal state or the client
+// disconnects, emitting a final `event: end` frame carrying the job's exit
+// code.
+func (s *Server) streamJobLogsSSE(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	job, err := s.jobManager.GetJob(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	follow := r.URL.Query().Get("follow") == "true"
+	since := parseSince(r.URL.Query().Get("since"))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	lastCursor := since
+	for _, entry := range job.LogsSince(since) {
+		writeSSELogEntry(w, entry)
+		lastCursor = entry.Cursor
+	}
+	flusher.Flush()
+
+	if !follow || job.IsComplete() {
+		writeSSEEnd(w, job)
+		flusher.Flush()
+		return
+	}
+
+	logCh, cancel := job.SubscribeLogs()
+	defer cancel()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry, ok := <-logCh:
+			if !ok {
+				return
+			}
+			if entry.Cursor <= lastCursor {
+				continue
+			}
+			writeSSELogEntry(w, entry)
+			lastCursor = entry.Cursor
+			flusher.Flush()
+
+			if job.IsComplete() {
+				writeSSEEnd(w, job)
+				flusher.Flush()
+				return
+			}
+		}
+	}
+}
+
+func writeSSELogEntry(w http.ResponseWriter, entry jobs.LogEntry) {
+	data, _ := json.Marshal(entry)
+	fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", entry.Cursor, data)
+}
+
+func writeSSEEnd(w http.ResponseWriter, job *jobs.Job) {
+	exitCode := 0
+	if job.ExitCode != nil {
+		exitCode = *job.ExitCode
+	}
+	data, _ := json.Marshal(map[string]interface{}{"status": job.Status, "exit_code": exitCode})
+	fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+}
+
+// streamJobLogsWS upgrades the connection to a WebSocket and multiplexes
+// stdout/stderr log frames using Docker's stdcopy framing: a 1-byte stream
+// id, 3 reserved bytes, a 4-byte big-endian payload length, then the
+// payload. Like the SSE endpoint it replays from ?since=<cursor> and
+// supports ?follow=true.
+func (s *Server) streamJobLogsWS(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	job, err := s.jobManager.GetJob(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	follow := r.URL.Query().Get("follow") == "true"
+	since := parseSince(r.URL.Query().Get("since"))
+
+	lastCursor := since
+	for _, entry := range job.LogsSince(since) {
+		if err := writeWSLogFrame(conn, entry); err != nil {
+			return
+		}
+		lastCursor = entry.Cursor
+	}
+
+	if !follow || job.IsComplete() {
+		return
+	}
+
+	logCh, cancel := job.SubscribeLogs()
+	defer cancel()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-logCh:
+			if !ok {
+				return
+			}
+			if entry.Cursor <= lastCursor {
+				continue
+			}
+			if err := writeWSLogFrame(conn, entry); err != nil {
+				return
+			}
+			lastCursor = entry.Cursor
+
+			if job.IsComplete() {
+				return
+			}
+		}
+	}
+}
+
+// writeWSLogFrame writes a single log entry onto conn using the
+// stream-id + reserved + length header docker stdcopy frames use.
+func writeWSLogFrame(conn *websocket.Conn, entry jobs.LogEntry) error {
+	payload := []byte(entry.Line)
+	frame := make([]byte, 8+len(payload))
+	frame[0] = wsStreamID(entry.Stream)
+	binary.BigEndian.PutUint32(frame[4:8], uint32(len(payload)))
+	copy(frame[8:], payload)
+	return conn.WriteMessage(websocket.BinaryMessage, frame)
+}
+
+func wsStreamID(stream jobs.LogStream) byte {
+	switch stream {
+	case jobs.LogStreamStderr:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// streamJobSSE streams a job's live output and status as Server-Sent
+// Events, using job.Broker so output from the executor is forwarded as it's
+// produced rather than only once the job exits. It resumes from
+// ?since=<cursor> or, if present, the `Last-Event-ID` header (so a
+// reconnecting EventSource picks up exactly where it left off), replays
+// buffered lines as `event: log`, then follows live: `event: log` for new
+// output, `event: status` on a running/cancelled transition, and a final
+// `event: done` once the job reaches a terminal state.
+func (s *Server) streamJobSSE(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	job, err := s.jobManager.GetJob(vars["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	since := parseSince(r.Header.Get("Last-Event-ID"))
+	if since < 0 {
+		since = parseSince(r.URL.Query().Get("since"))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	broker := job.Broker()
+	lastCursor := since
+	for _, evt := range broker.ReplaySince(since) {
+		writeSSEBrokerEvent(w, evt)
+		lastCursor = evt.Log.Cursor
+	}
+	flusher.Flush()
+
+	if job.IsComplete() {
+		writeSSEBrokerEvent(w, jobs.BrokerEvent{Kind: jobs.BrokerEventDone, Status: job.Status, ExitCode: job.ExitCode})
+		flusher.Flush()
+		return
+	}
+
+	events, cancel := broker.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.Kind == jobs.BrokerEventLog && evt.Log.Cursor <= lastCursor {
+				continue
+			}
+			writeSSEBrokerEvent(w, evt)
+			if evt.Kind == jobs.BrokerEventLog {
+				lastCursor = evt.Log.Cursor
+			}
+			flusher.Flush()
+
+			if evt.Kind == jobs.BrokerEventDone {
+				return
+			}
+		}
+	}
+}
+
+// writeSSEBrokerEvent writes a single Broker frame as an SSE event. Log
+// frames carry an `id:` line (the entry's cursor) so a client's
+// `Last-Event-ID` resumes correctly; status/done frames don't advance the
+// cursor.
+func writeSSEBrokerEvent(w http.ResponseWriter, evt jobs.BrokerEvent) {
+	switch evt.Kind {
+	case jobs.BrokerEventLog:
+		data, _ := json.Marshal(evt.Log)
+		fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", evt.Log.Cursor, data)
+	case jobs.BrokerEventStatus:
+		data, _ := json.Marshal(map[string]interface{}{"status": evt.Status})
+		fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+	case jobs.BrokerEventDone:
+		exitCode := 0
+		if evt.ExitCode != nil {
+			exitCode = *evt.ExitCode
+		}
+		data, _ := json.Marshal(map[string]interface{}{"status": evt.Status, "exit_code": exitCode})
+		fmt.Fprintf(w, "event: done\ndata: %s\n\n", data)
+	}
+}
+
+// wsControlMessage is a client->server frame on the /ws endpoint. Currently
+// only {"action":"cancel"} is recognized, which cancels the job.
+type wsControlMessage struct {
+	Action string `json:"action"`
+}
+
+// streamJobWS upgrades the connection to a WebSocket and streams the same
+// log/status/done frames as streamJobSSE, each as a JSON text message. It
+// also reads client frames off the connection so a {"action":"cancel"}
+// message can cancel the job mid-stream.
+func (s *Server) streamJobWS(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+	job, err := s.jobManager.GetJob(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	since := parseSince(r.URL.Query().Get("since"))
+
+	ctx, cancelCtx := context.WithCancel(r.Context())
+	defer cancelCtx()
+
+	go func() {
+		defer cancelCtx()
+		for {
+			var msg wsControlMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			if msg.Action == "cancel" {
+				if err := s.jobManager.CancelJob(id); err != nil {
+					log.Printf("cancel via websocket failed: %v", err)
+				}
+			}
+		}
+	}()
+
+	broker := job.Broker()
+	lastCursor := since
+	for _, evt := range broker.ReplaySince(since) {
+		if err := conn.WriteJSON(evt); err != nil {
+			return
+		}
+		lastCursor = evt.Log.Cursor
+	}
+
+	if job.IsComplete() {
+		conn.WriteJSON(jobs.BrokerEvent{Kind: jobs.BrokerEventDone, Status: job.Status, ExitCode: job.ExitCode})
+		return
+	}
+
+	events, cancel := broker.Subscribe()
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-events:
+			if !ok {
+				return
+			}
+			if evt.Kind == jobs.BrokerEventLog && evt.Log.Cursor <= lastCursor {
+				continue
+			}
+			if err := conn.WriteJSON(evt); err != nil {
+				return
+			}
+			if evt.Kind == jobs.BrokerEventLog {
+				lastCursor = evt.Log.Cursor
+			}
+
+			if evt.Kind == jobs.BrokerEventDone {
+				return
+			}
+		}
+	}
+}
+
+// parseSince returns -1, which replays from the first line (cursor 0), when
+// raw is empty or not a number.
+func parseSince(raw string) int {
+	if raw == "" {
+		return -1
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+func (s *Server) createWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := s.jobManager.RegisterWebhook(req.URL)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+func (s *Server) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := s.jobManager.RemoveWebhook(id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	deliveries, err := s.jobManager.WebhookDeliveries(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
 func (s *Server) listCommands(w http.ResponseWriter, r *http.Request) {
 	commands := getCommandMappings()
 
@@ -243,11 +848,22 @@ func (s *Server) getCommand(w http.ResponseWriter, r *http.Request) {
 	http.Error(w, "Command not found", http.StatusNotFound)
 }
 
+// listActions returns the registered action catalog (see config.Config.
+// Actions / jobs.ActionRegistry), reusing the same CommandMapping shape as
+// GET /commands so UIs can render either with one form renderer.
+func (s *Server) listActions(w http.ResponseWriter, r *http.Request) {
+	actions := s.jobManager.Actions().List()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(actions)
+}
+
 func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 	health := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now(),
 		"version":   "1.0.0",
+		"ready":     !s.jobManager.IsDraining(),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"plandex-cli-api/config"
+)
+
+// clientCertCNKey is the context key authMiddleware reads the verified
+// client certificate's CommonName from, when mTLS is enabled.
+type clientCertCNKey struct{}
+
+// clientCertCN returns the CommonName of the client certificate presented on
+// r's connection, if mTLS verification succeeded, and whether one was
+// present at all.
+func clientCertCN(ctx context.Context) (string, bool) {
+	cn, ok := ctx.Value(clientCertCNKey{}).(string)
+	return cn, ok
+}
+
+// buildTLSConfig turns a config.TLSConfig into a *tls.Config enforcing at
+// least the configured minimum version and, if ClientCAFile is set,
+// verifying client certs for mTLS.
+func buildTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.MinVersion == "1.3" {
+		minVersion = tls.VersionTLS13
+	}
+
+	tlsCfg := &tls.Config{
+		MinVersion: minVersion,
+	}
+
+	if cfg.ClientCAFile != "" {
+		caCert, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse client CA file: %s", cfg.ClientCAFile)
+		}
+
+		tlsCfg.ClientCAs = pool
+		if cfg.RequireClientCert {
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		} else {
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+	}
+
+	return tlsCfg, nil
+}
+
+// withClientCertCN stashes the CommonName of the request's verified client
+// certificate, if any, into the request context ahead of authMiddleware.
+func withClientCertCN(ctx context.Context, state *tls.ConnectionState) context.Context {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, clientCertCNKey{}, state.PeerCertificates[0].Subject.CommonName)
+}
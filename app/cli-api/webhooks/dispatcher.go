@@ -0,0 +1,380 @@
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"plandex-cli-api/config"
+)
+
+// Event identifies a job lifecycle transition that a registered endpoint can
+// subscribe to.
+type Event string
+
+const (
+	EventJobCreated   Event = "job.created"
+	EventJobRunning   Event = "job.running"
+	EventJobCompleted Event = "job.completed"
+	EventJobFailed    Event = "job.failed"
+	EventJobCancelled Event = "job.cancelled"
+)
+
+// Endpoint is a user-registered webhook subscription.
+type Endpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// EventEnvelope is the JSON body POSTed to registered endpoints on every job
+// lifecycle transition.
+type EventEnvelope struct {
+	Event       Event                  `json:"event"`
+	JobID       string                 `json:"job_id"`
+	Status      string                 `json:"status"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Output      string                 `json:"output,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	ExitCode    *int                   `json:"exit_code,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Delivery records the outcome of delivering an event to an endpoint, across
+// all of its retry attempts.
+type Delivery struct {
+	ID           string     `json:"id"`
+	EndpointID   string     `json:"endpoint_id"`
+	Event        Event      `json:"event"`
+	JobID        string     `json:"job_id"`
+	Attempts     int        `json:"attempts"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Delivered    bool       `json:"delivered"`
+	DeadLettered bool       `json:"dead_lettered"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+// delivery task queued on the dispatcher's work channel.
+type task struct {
+	endpoint Endpoint
+	envelope EventEnvelope
+	delivery *Delivery
+	attempt  int
+}
+
+// maxDeliveriesPerEndpoint bounds the delivery history retained in memory
+// (and persisted) for a single endpoint. Without a cap, a long-lived
+// endpoint's history grows forever and so does the payload GET
+// /webhooks/{id}/deliveries returns.
+const maxDeliveriesPerEndpoint = 200
+
+// PersistentStore is implemented by a store that can keep webhook endpoints
+// and delivery history alongside whatever else it persists, so both survive
+// a restart. jobs.Store satisfies this.
+type PersistentStore interface {
+	SaveWebhookEndpoint(ep Endpoint) error
+	DeleteWebhookEndpoint(id string) error
+	ListWebhookEndpoints() ([]Endpoint, error)
+	SaveWebhookDeliveries(endpointID string, deliveries []*Delivery) error
+	ListWebhookDeliveries() (map[string][]*Delivery, error)
+}
+
+// Dispatcher owns the set of registered webhook endpoints and fans out job
+// lifecycle events to them via a bounded worker pool, retrying failed
+// deliveries with exponential backoff up to Webhooks.MaxRetries.
+type Dispatcher struct {
+	config *config.Config
+	store  PersistentStore
+
+	mu         sync.RWMutex
+	endpoints  map[string]Endpoint
+	deliveries map[string][]*Delivery // keyed by endpoint ID
+
+	httpClient *http.Client
+	queue      chan task
+}
+
+// NewDispatcher creates a Dispatcher, rehydrates it from store, and starts
+// its worker pool. Workers run until the process exits; there is one
+// dispatcher per Manager. store may be nil, in which case registrations and
+// delivery history don't survive a restart (e.g. in tests).
+func NewDispatcher(cfg *config.Config, store PersistentStore) *Dispatcher {
+	d := &Dispatcher{
+		config:     cfg,
+		store:      store,
+		endpoints:  make(map[string]Endpoint),
+		deliveries: make(map[string][]*Delivery),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		queue:      make(chan task, 256),
+	}
+	d.rehydrate()
+
+	workers := cfg.Webhooks.MaxRetries + 1
+	if workers < 4 {
+		workers = 4
+	}
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+
+	return d
+}
+
+// rehydrate restores registered endpoints and their delivery history from
+// store so both survive a restart.
+func (d *Dispatcher) rehydrate() {
+	if d.store == nil {
+		return
+	}
+
+	endpoints, err := d.store.ListWebhookEndpoints()
+	if err != nil {
+		log.Printf("failed to load persisted webhook endpoints: %v", err)
+		return
+	}
+	for _, ep := range endpoints {
+		d.endpoints[ep.ID] = ep
+	}
+
+	deliveries, err := d.store.ListWebhookDeliveries()
+	if err != nil {
+		log.Printf("failed to load persisted webhook deliveries: %v", err)
+		return
+	}
+	for id, dels := range deliveries {
+		d.deliveries[id] = dels
+	}
+}
+
+// RegisterEndpoint adds a new webhook subscription.
+func (d *Dispatcher) RegisterEndpoint(url string) (*Endpoint, error) {
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+
+	ep := Endpoint{
+		ID:        uuid.New().String(),
+		URL:       url,
+		CreatedAt: time.Now(),
+	}
+
+	d.mu.Lock()
+	d.endpoints[ep.ID] = ep
+	d.mu.Unlock()
+
+	if d.store != nil {
+		if err := d.store.SaveWebhookEndpoint(ep); err != nil {
+			log.Printf("failed to persist webhook endpoint %s: %v", ep.ID, err)
+		}
+	}
+
+	return &ep, nil
+}
+
+// RemoveEndpoint deletes a webhook subscription.
+func (d *Dispatcher) RemoveEndpoint(id string) error {
+	d.mu.Lock()
+	if _, exists := d.endpoints[id]; !exists {
+		d.mu.Unlock()
+		return fmt.Errorf("webhook endpoint not found: %s", id)
+	}
+	delete(d.endpoints, id)
+	delete(d.deliveries, id)
+	d.mu.Unlock()
+
+	if d.store != nil {
+		if err := d.store.DeleteWebhookEndpoint(id); err != nil {
+			log.Printf("failed to delete persisted webhook endpoint %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Deliveries returns deep copies of the delivery history for an endpoint,
+// most recent last. Copies are returned (rather than the stored pointers)
+// because workers mutate deliveries in place as attempts land.
+func (d *Dispatcher) Deliveries(endpointID string) ([]*Delivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, exists := d.endpoints[endpointID]; !exists {
+		return nil, fmt.Errorf("webhook endpoint not found: %s", endpointID)
+	}
+
+	hist := d.deliveries[endpointID]
+	out := make([]*Delivery, len(hist))
+	for i, del := range hist {
+		cp := *del
+		out[i] = &cp
+	}
+	return out, nil
+}
+
+// Dispatch enqueues delivery of envelope to every registered endpoint. It
+// never blocks the caller's goroutine on network I/O.
+func (d *Dispatcher) Dispatch(envelope EventEnvelope) {
+	if !d.config.Webhooks.Enabled {
+		return
+	}
+
+	d.mu.RLock()
+	endpoints := make([]Endpoint, 0, len(d.endpoints))
+	for _, ep := range d.endpoints {
+		endpoints = append(endpoints, ep)
+	}
+	d.mu.RUnlock()
+
+	for _, ep := range endpoints {
+		delivery := &Delivery{
+			ID:         uuid.New().String(),
+			EndpointID: ep.ID,
+			Event:      envelope.Event,
+			JobID:      envelope.JobID,
+			CreatedAt:  time.Now(),
+		}
+
+		d.mu.Lock()
+		hist := append(d.deliveries[ep.ID], delivery)
+		if len(hist) > maxDeliveriesPerEndpoint {
+			hist = hist[len(hist)-maxDeliveriesPerEndpoint:]
+		}
+		d.deliveries[ep.ID] = hist
+		d.mu.Unlock()
+
+		select {
+		case d.queue <- task{endpoint: ep, envelope: envelope, delivery: delivery}:
+		default:
+			log.Printf("webhook queue full, dropping delivery %s to endpoint %s", delivery.ID, ep.ID)
+			d.mu.Lock()
+			delivery.LastError = "delivery queue full"
+			delivery.DeadLettered = true
+			d.mu.Unlock()
+		}
+
+		d.persistDeliveries(ep.ID)
+	}
+}
+
+// persistDeliveries snapshots the current delivery history for endpointID
+// under the lock and writes it to store. It's a no-op when store is nil.
+func (d *Dispatcher) persistDeliveries(endpointID string) {
+	if d.store == nil {
+		return
+	}
+
+	d.mu.RLock()
+	hist := d.deliveries[endpointID]
+	snapshot := make([]*Delivery, len(hist))
+	for i, del := range hist {
+		cp := *del
+		snapshot[i] = &cp
+	}
+	d.mu.RUnlock()
+
+	if err := d.store.SaveWebhookDeliveries(endpointID, snapshot); err != nil {
+		log.Printf("failed to persist webhook deliveries for endpoint %s: %v", endpointID, err)
+	}
+}
+
+// worker drains the delivery queue, retrying failed sends with exponential
+// backoff until MaxRetries is exhausted.
+func (d *Dispatcher) worker() {
+	for t := range d.queue {
+		d.attemptDelivery(t)
+	}
+}
+
+func (d *Dispatcher) attemptDelivery(t task) {
+	maxRetries := d.config.Webhooks.MaxRetries
+	backoff := d.config.Webhooks.RetryBackoff
+
+	for {
+		t.attempt++
+
+		statusCode, err := d.send(t.endpoint.URL, t.envelope)
+		if err == nil {
+			now := time.Now()
+			d.mu.Lock()
+			t.delivery.Attempts = t.attempt
+			t.delivery.Delivered = true
+			t.delivery.StatusCode = statusCode
+			t.delivery.DeliveredAt = &now
+			d.mu.Unlock()
+			d.persistDeliveries(t.endpoint.ID)
+			return
+		}
+
+		d.mu.Lock()
+		t.delivery.Attempts = t.attempt
+		t.delivery.StatusCode = statusCode
+		t.delivery.LastError = err.Error()
+		d.mu.Unlock()
+		log.Printf("webhook delivery attempt %d failed for endpoint %s: %v", t.attempt, t.endpoint.ID, err)
+
+		if t.attempt > maxRetries {
+			d.mu.Lock()
+			t.delivery.DeadLettered = true
+			d.mu.Unlock()
+			log.Printf("webhook delivery dead-lettered for endpoint %s after %d attempts", t.endpoint.ID, t.attempt)
+			d.persistDeliveries(t.endpoint.ID)
+			return
+		}
+
+		time.Sleep(backoff * time.Duration(1<<uint(t.attempt-1)))
+	}
+}
+
+// send POSTs the envelope to url, signing the body with HMAC-SHA256 when a
+// webhook secret is configured.
+func (d *Dispatcher) send(url string, envelope EventEnvelope) (int, error) {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "plandex-cli-api/1.0")
+
+	if d.config.Webhooks.Secret != "" {
+		req.Header.Set("X-Plandex-Signature", signPayload(payload, d.config.Webhooks.Secret))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// signPayload computes the HMAC-SHA256 signature used in the
+// X-Plandex-Signature header, following the same "sha256=<hex>" scheme
+// GitHub uses so consumers can reuse existing verifiers.
+func signPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestJobTerminalOrdering races 1000 concurrent "complete" attempts against
+// a concurrent cancel and asserts the job's run loop delivers exactly one
+// terminal transition to subscribers (onStatusChange), and that whenever
+// completion is the one that wins, its result was already applied before
+// the terminal status was observed.
+func TestJobTerminalOrdering(t *testing.T) {
+	job := &Job{ID: "race-test", Status: JobStatusRunning, CreatedAt: time.Now()}
+
+	var terminalEvents int32
+	var completedWithResult int32
+	var mu sync.Mutex
+	var observed []JobStatus
+
+	job.onStatusChange = func(j *Job) {
+		atomic.AddInt32(&terminalEvents, 1)
+
+		j.mu.RLock()
+		status := j.Status
+		hasResult := j.Result != nil
+		j.mu.RUnlock()
+
+		if status == JobStatusCompleted && hasResult {
+			atomic.AddInt32(&completedWithResult, 1)
+		}
+
+		mu.Lock()
+		observed = append(observed, status)
+		mu.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			job.SetResult(map[string]interface{}{"n": i})
+			job.SetStatus(JobStatusCompleted)
+		}(i)
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		job.SetStatus(JobStatusCancelled)
+	}()
+
+	// wg.Wait returning means every goroutine above has returned from its
+	// enqueue call(s), which means ensureRunning's sync.Once has already
+	// run - so reading job.done below is safe without its own lock.
+	wg.Wait()
+
+	select {
+	case <-job.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("job never reached a terminal state")
+	}
+
+	if got := atomic.LoadInt32(&terminalEvents); got != 1 {
+		t.Fatalf("want exactly 1 terminal event delivered to subscribers, got %d", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(observed) != 1 {
+		t.Fatalf("want exactly 1 observed status transition, got %v", observed)
+	}
+	if observed[0] != JobStatusCompleted && observed[0] != JobStatusCancelled {
+		t.Fatalf("unexpected winning status %v", observed[0])
+	}
+	if observed[0] == JobStatusCompleted && atomic.LoadInt32(&completedWithResult) != 1 {
+		t.Fatal("completed was observed before its result was applied")
+	}
+}
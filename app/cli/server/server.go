@@ -8,12 +8,14 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
 
 	"plandex-cli/api"
@@ -25,6 +27,14 @@ import (
 	shared "plandex-shared"
 )
 
+// wsUpgrader upgrades /logs connections that ask for a WebSocket. Origin
+// checking is left to the API key/auth middleware in front of it.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
 // Config represents the server configuration
 type Config struct {
 	Server   ServerConfig   `json:"server"`
@@ -41,6 +51,47 @@ type ServerConfig struct {
 	ReadTimeout  string `json:"read_timeout"`
 	WriteTimeout string `json:"write_timeout"`
 	IdleTimeout  string `json:"idle_timeout"`
+
+	// TLS configures optional TLS/mTLS termination. When neither TLS.Enabled
+	// nor TLS.AutoCert.Enabled is set, the server listens over plain HTTP.
+	TLS TLSConfig `json:"tls"`
+}
+
+// TLSConfig configures TLS termination for the API server, either from a
+// cert/key pair on disk or via ACME/Let's Encrypt, plus optional mTLS
+// client certificate auth.
+type TLSConfig struct {
+	Enabled  bool           `json:"enabled"`
+	CertFile string         `json:"cert_file,omitempty"`
+	KeyFile  string         `json:"key_file,omitempty"`
+	AutoCert AutoCertConfig `json:"auto_cert"`
+
+	ClientAuth ClientAuthConfig `json:"client_auth"`
+}
+
+// AutoCertConfig enables automatic certificate issuance and renewal via
+// ACME/Let's Encrypt (golang.org/x/crypto/acme/autocert). When Enabled, it
+// takes precedence over TLS.CertFile/KeyFile and an HTTP->HTTPS redirector
+// is started on :80 to serve ACME's http-01 challenge.
+type AutoCertConfig struct {
+	Enabled  bool     `json:"enabled"`
+	Domains  []string `json:"domains"`
+	CacheDir string   `json:"cache_dir"`
+	Email    string   `json:"email,omitempty"`
+}
+
+// ClientAuthConfig configures mTLS, verifying the client certificate
+// against CAFile and, for Mode "request" or "require", authorizing it as an alternative
+// to X-API-Key if its CommonName or a SAN appears in AllowedCNs/AllowedSANs
+// (empty allow-lists mean "any cert signed by CAFile is authorized").
+type ClientAuthConfig struct {
+	// Mode is "none" (default), "request" (verify if presented but don't
+	// require it), or "require" (reject the TLS handshake without a valid
+	// client cert).
+	Mode        string   `json:"mode"`
+	CAFile      string   `json:"ca_file,omitempty"`
+	AllowedCNs  []string `json:"allowed_cns,omitempty"`
+	AllowedSANs []string `json:"allowed_sans,omitempty"`
 }
 
 type AuthConfig struct {
@@ -60,6 +111,18 @@ type JobsConfig struct {
 	DefaultTTL      string `json:"default_ttl"`
 	CleanupInterval string `json:"cleanup_interval"`
 	MaxHistorySize  int    `json:"max_history_size"`
+
+	// StoreDriver selects the JobStore backend: "sqlite" (default, no cgo
+	// via modernc.org/sqlite) or "postgres". StoreDSN is the sqlite file
+	// path or the Postgres connection string, respectively.
+	StoreDriver string `json:"store_driver"`
+	StoreDSN    string `json:"store_dsn"`
+
+	// ResumeOnStart re-enqueues jobs that were still pending/running when
+	// the server last stopped, provided their plan is still resumable via
+	// the Plandex API. When false (the default) such jobs are simply
+	// marked failed with error "server restarted".
+	ResumeOnStart bool `json:"resume_on_start"`
 }
 
 type WebhooksConfig struct {
@@ -85,8 +148,276 @@ type Job struct {
 	CompletedAt *time.Time             `json:"completed_at,omitempty"`
 	Result      map[string]interface{} `json:"result,omitempty"`
 	Error       string                 `json:"error,omitempty"`
-	cancel      context.CancelFunc
-	mu          sync.RWMutex
+
+	// Fields needed to persist and, on restart, replay or resume the job.
+	Prompt      string `json:"prompt,omitempty"`
+	IsChatOnly  bool   `json:"is_chat_only"`
+	AutoContext bool   `json:"auto_context"`
+	AutoApply   bool   `json:"auto_apply"`
+	PlanID      string `json:"plan_id,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+
+	// IsBatch marks a job created by /chat/batch or /tell/batch, whose
+	// Result is a BatchJobResult rather than a single TellPlan response.
+	// Batch jobs are never replayed on restart (recoverUnfinishedJobs has
+	// no per-item prompt list to resume from), only marked failed.
+	IsBatch bool `json:"is_batch,omitempty"`
+
+	// Requester is the authenticated subject (API key label, JWT subject,
+	// or verified mTLS client cert CN/SAN) that created the job, if auth
+	// is enabled. Carried through to webhook deliveries so a consumer can
+	// attribute the event.
+	Requester string `json:"requester,omitempty"`
+
+	cancel context.CancelFunc
+	mu     sync.RWMutex
+
+	// store, when set, receives a write-through Save on every status,
+	// result, and error change so the job survives a restart. Installed by
+	// JobManager.AddJob.
+	store JobStore
+
+	// logs is a bounded ring buffer of the job's captured stdout/stderr,
+	// populated by the output capture executeJobAsync installs in place of
+	// the (disabled) TUI sink. logOffset is the cumulative byte count
+	// written so far, letting clients resume a stream with ?since=<offset>.
+	logs      []LogEntry
+	logOffset int
+	logSubs   map[int]chan LogEntry
+	nextSubID int
+
+	// onStatusChange, when set, is invoked after every terminal or status
+	// update so the owning APIServer can fan the transition out to the
+	// webhook dispatcher. It must not block; WebhookDispatcher.Dispatch
+	// already queues deliveries asynchronously.
+	onStatusChange func(*Job)
+
+	// updates is the single serialized channel every mutation (log chunk,
+	// intermediate result/error, status transition) flows through. One
+	// goroutine (run) consumes it and applies changes under mu, which
+	// guarantees the terminal update is always the last one applied and is
+	// always applied after whatever result/error preceded it, regardless
+	// of how many goroutines are racing to mutate the job concurrently.
+	updates chan jobUpdate
+	done    chan struct{} // closed once a terminal update has been applied
+	runOnce sync.Once
+}
+
+// jobUpdateKind identifies which part of a Job a jobUpdate mutates.
+type jobUpdateKind int
+
+const (
+	jobUpdateLog jobUpdateKind = iota
+	jobUpdateState
+)
+
+// jobUpdate is one mutation queued on a Job's update channel. hasResult,
+// hasError and hasStatus indicate which fields this update carries;
+// terminal marks a status transition into a terminal JobStatus, which the
+// run loop guarantees is the last update it ever applies.
+type jobUpdate struct {
+	kind jobUpdateKind
+
+	logStream string
+	logData   []byte
+
+	hasStatus bool
+	status    JobStatus
+	hasResult bool
+	result    map[string]interface{}
+	hasError  bool
+	errMsg    string
+	terminal  bool
+}
+
+func isTerminalStatus(status JobStatus) bool {
+	switch status {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// ensureRunning lazily starts the job's single update-consuming goroutine.
+func (j *Job) ensureRunning() {
+	j.runOnce.Do(func() {
+		j.updates = make(chan jobUpdate, 256)
+		j.done = make(chan struct{})
+		go j.run()
+	})
+}
+
+// enqueue hands u to the job's run loop. Once a terminal update has been
+// applied, done is closed and further updates are silently dropped rather
+// than applied out of turn - this is what guarantees subscribers never
+// observe a state change after the job's terminal event.
+func (j *Job) enqueue(u jobUpdate) {
+	j.ensureRunning()
+	select {
+	case j.updates <- u:
+	case <-j.done:
+		log.Printf("job %s: dropping update received after terminal state", j.ID)
+	}
+}
+
+// run is the job's single writer: it applies every queued update in order,
+// so a terminal transition can never be observed ahead of (or instead of)
+// the result/error update that preceded it. It exits as soon as the first
+// terminal update has been applied.
+func (j *Job) run() {
+	for u := range j.updates {
+		if j.apply(u) {
+			close(j.done)
+			return
+		}
+	}
+}
+
+// apply performs one queued mutation under the job's mutex, then fans the
+// change out to log subscribers and the webhook dispatcher outside the
+// lock. It reports whether u was a terminal update.
+func (j *Job) apply(u jobUpdate) bool {
+	j.mu.Lock()
+
+	var logEntry LogEntry
+	var logSubsSnapshot []chan LogEntry
+
+	switch u.kind {
+	case jobUpdateLog:
+		logEntry = LogEntry{
+			Offset:    j.logOffset,
+			Timestamp: time.Now(),
+			Stream:    u.logStream,
+			Data:      u.logData,
+		}
+		j.logOffset += len(u.logData)
+		j.logs = append(j.logs, logEntry)
+		if len(j.logs) > maxLogEntries {
+			j.logs = j.logs[len(j.logs)-maxLogEntries:]
+		}
+		logSubsSnapshot = make([]chan LogEntry, 0, len(j.logSubs))
+		for _, ch := range j.logSubs {
+			logSubsSnapshot = append(logSubsSnapshot, ch)
+		}
+
+	case jobUpdateState:
+		if u.hasResult {
+			j.Result = u.result
+		}
+		if u.hasError {
+			j.Error = u.errMsg
+		}
+		if u.hasStatus {
+			j.Status = u.status
+			now := time.Now()
+			if u.status == JobStatusRunning && j.StartedAt == nil {
+				j.StartedAt = &now
+			} else if isTerminalStatus(u.status) && j.CompletedAt == nil {
+				j.CompletedAt = &now
+			}
+		}
+		if u.terminal {
+			logSubsSnapshot = make([]chan LogEntry, 0, len(j.logSubs))
+			for _, ch := range j.logSubs {
+				logSubsSnapshot = append(logSubsSnapshot, ch)
+			}
+		}
+	}
+
+	j.saveLocked()
+	onStatusChange := j.onStatusChange
+	j.mu.Unlock()
+
+	for _, ch := range logSubsSnapshot {
+		if u.kind == jobUpdateLog {
+			select {
+			case ch <- logEntry:
+			default:
+			}
+		}
+		if u.terminal {
+			close(ch)
+		}
+	}
+
+	if u.kind == jobUpdateState && u.hasStatus && onStatusChange != nil {
+		onStatusChange(j)
+	}
+
+	return u.terminal
+}
+
+// maxLogEntries bounds how many log chunks a job retains for replay; older
+// chunks are dropped once a job exceeds it.
+const maxLogEntries = 1000
+
+// LogEntry is a chunk of a job's captured output, tagged with the byte
+// offset it starts at so clients can resume a stream with ?since=<offset>.
+type LogEntry struct {
+	Offset    int       `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+	Stream    string    `json:"stream"`
+	Data      []byte    `json:"data"`
+}
+
+// appendLog queues a chunk of output for the job's run loop, which records
+// it and fans it out to any live subscribers. Slow subscribers have chunks
+// dropped rather than blocking the writer.
+func (j *Job) appendLog(stream string, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	j.enqueue(jobUpdate{
+		kind:      jobUpdateLog,
+		logStream: stream,
+		logData:   append([]byte(nil), p...),
+	})
+}
+
+// LogsSince returns every buffered chunk that has bytes past offset.
+func (j *Job) LogsSince(offset int) []LogEntry {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	var out []LogEntry
+	for _, e := range j.logs {
+		if e.Offset+len(e.Data) > offset {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// SubscribeLogs registers a channel that receives every chunk appended
+// after this call. The returned cancel func must be called once the
+// subscriber is done to avoid leaking the channel.
+func (j *Job) SubscribeLogs() (<-chan LogEntry, func()) {
+	j.mu.Lock()
+	if j.logSubs == nil {
+		j.logSubs = make(map[int]chan LogEntry)
+	}
+	id := j.nextSubID
+	j.nextSubID++
+	ch := make(chan LogEntry, 64)
+	j.logSubs[id] = ch
+	j.mu.Unlock()
+
+	return ch, func() {
+		j.mu.Lock()
+		delete(j.logSubs, id)
+		j.mu.Unlock()
+	}
+}
+
+// IsComplete reports whether the job has reached a terminal state.
+func (j *Job) IsComplete() bool {
+	switch j.GetStatus() {
+	case JobStatusCompleted, JobStatusFailed, JobStatusCancelled:
+		return true
+	default:
+		return false
+	}
 }
 
 type JobStatus string
@@ -99,28 +430,57 @@ const (
 	JobStatusCancelled JobStatus = "cancelled"
 )
 
+// SetStatus queues a status transition on the job's serialized update
+// channel. A terminal status (completed/failed/cancelled) is guaranteed to
+// be applied - and observed by subscribers - after any SetResult/SetError
+// call that precedes it on the same goroutine, and to be the last update
+// the job ever applies: once one terminal update lands, every later
+// update (from any goroutine) is dropped.
 func (j *Job) SetStatus(status JobStatus) {
-	j.mu.Lock()
-	defer j.mu.Unlock()
-	j.Status = status
-	now := time.Now()
-	if status == JobStatusRunning && j.StartedAt == nil {
-		j.StartedAt = &now
-	} else if (status == JobStatusCompleted || status == JobStatusFailed || status == JobStatusCancelled) && j.CompletedAt == nil {
-		j.CompletedAt = &now
-	}
+	j.enqueue(jobUpdate{kind: jobUpdateState, hasStatus: true, status: status, terminal: isTerminalStatus(status)})
 }
 
 func (j *Job) SetResult(result map[string]interface{}) {
-	j.mu.Lock()
-	defer j.mu.Unlock()
-	j.Result = result
+	j.enqueue(jobUpdate{kind: jobUpdateState, hasResult: true, result: result})
 }
 
 func (j *Job) SetError(error string) {
-	j.mu.Lock()
-	defer j.mu.Unlock()
-	j.Error = error
+	j.enqueue(jobUpdate{kind: jobUpdateState, hasError: true, errMsg: error})
+}
+
+// saveLocked write-throughs the job to its store, if any. Callers must
+// already hold j.mu. Persistence errors are logged rather than returned so
+// a degraded store never blocks a job's goroutine.
+func (j *Job) saveLocked() {
+	if j.store == nil {
+		return
+	}
+	if err := j.store.Save(j.toRecordLocked()); err != nil {
+		log.Printf("job %s: failed to persist to job store: %v", j.ID, err)
+	}
+}
+
+// toRecordLocked snapshots the job into its persisted representation.
+// Callers must already hold j.mu.
+func (j *Job) toRecordLocked() *JobRecord {
+	return &JobRecord{
+		ID:          j.ID,
+		Status:      j.Status,
+		CreatedAt:   j.CreatedAt,
+		StartedAt:   j.StartedAt,
+		CompletedAt: j.CompletedAt,
+		Result:      j.Result,
+		Error:       j.Error,
+		Prompt:      j.Prompt,
+		IsChatOnly:  j.IsChatOnly,
+		AutoContext: j.AutoContext,
+		AutoApply:   j.AutoApply,
+		PlanID:      j.PlanID,
+		Branch:      j.Branch,
+		Requester:   j.Requester,
+		IsBatch:     j.IsBatch,
+		Logs:        append([]LogEntry(nil), j.logs...),
+	}
 }
 
 func (j *Job) GetStatus() JobStatus {
@@ -134,19 +494,43 @@ type JobManager struct {
 	jobs      map[string]*Job
 	mu        sync.RWMutex
 	semaphore chan struct{}
+	store     JobStore
 }
 
-func NewJobManager(maxConcurrent int) *JobManager {
+func NewJobManager(maxConcurrent int, store JobStore) *JobManager {
 	return &JobManager{
 		jobs:      make(map[string]*Job),
 		semaphore: make(chan struct{}, maxConcurrent),
+		store:     store,
 	}
 }
 
+// AddJob registers job in memory and wires it to the manager's store so
+// every later SetStatus/SetResult/SetError write-through persists it.
 func (jm *JobManager) AddJob(job *Job) {
+	job.store = jm.store
+
 	jm.mu.Lock()
-	defer jm.mu.Unlock()
 	jm.jobs[job.ID] = job
+	jm.mu.Unlock()
+
+	job.mu.Lock()
+	job.saveLocked()
+	job.mu.Unlock()
+}
+
+// DeleteJob removes a job from memory and, if the manager has a store,
+// from persistent storage too. Used by the TTL sweeper.
+func (jm *JobManager) DeleteJob(id string) {
+	jm.mu.Lock()
+	delete(jm.jobs, id)
+	jm.mu.Unlock()
+
+	if jm.store != nil {
+		if err := jm.store.Delete(id); err != nil {
+			log.Printf("failed to delete job %s from store: %v", id, err)
+		}
+	}
 }
 
 func (jm *JobManager) GetJob(id string) (*Job, bool) {
@@ -168,23 +552,52 @@ func (jm *JobManager) ListJobs() map[string]*Job {
 
 // APIServer represents the HTTP API server
 type APIServer struct {
-	config     *Config
-	router     *mux.Router
-	server     *http.Server
-	jobManager *JobManager
-	workingDir string
+	config            *Config
+	router            *mux.Router
+	server            *http.Server
+	jobManager        *JobManager
+	webhookDispatcher *WebhookDispatcher
+	workingDir        string
 }
 
 // NewServer creates a new API server instance
-func NewServer(config *Config) *APIServer {
-	jobManager := NewJobManager(config.Jobs.MaxConcurrent)
+func NewServer(config *Config) (*APIServer, error) {
+	store, err := NewJobStore(config.Jobs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open job store: %w", err)
+	}
+
+	jobManager := NewJobManager(config.Jobs.MaxConcurrent, store)
 
 	return &APIServer{
-		config:     config,
-		router:     mux.NewRouter(),
-		jobManager: jobManager,
-		workingDir: config.CLI.WorkingDir,
+		config:            config,
+		router:            mux.NewRouter(),
+		jobManager:        jobManager,
+		webhookDispatcher: NewWebhookDispatcher(config.Webhooks, store),
+		workingDir:        config.CLI.WorkingDir,
+	}, nil
+}
+
+// emitJobWebhook builds the webhook envelope for a job's current state and
+// hands it to the dispatcher. Installed as Job.onStatusChange so every
+// transition (pending->running->completed|failed|cancelled) reaches
+// registered endpoints without the job goroutine blocking on delivery.
+func (s *APIServer) emitJobWebhook(job *Job) {
+	job.mu.RLock()
+	envelope := WebhookEnvelope{
+		Event:       string(job.Status),
+		JobID:       job.ID,
+		Status:      string(job.Status),
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		CompletedAt: job.CompletedAt,
+		Result:      job.Result,
+		Error:       job.Error,
+		Requester:   job.Requester,
 	}
+	job.mu.RUnlock()
+
+	s.webhookDispatcher.Dispatch(envelope)
 }
 
 // Start starts the API server
@@ -194,7 +607,10 @@ func Start(configFile string) {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
-	server := NewServer(config)
+	server, err := NewServer(config)
+	if err != nil {
+		log.Fatalf("Failed to create server: %v", err)
+	}
 
 	if err := server.initialize(); err != nil {
 		log.Fatalf("Failed to initialize server: %v", err)
@@ -226,10 +642,84 @@ func (s *APIServer) initialize() error {
 		return fmt.Errorf("CLI setup required: %v", err)
 	}
 
+	s.recoverUnfinishedJobs()
+
 	s.setupRoutes()
 	return nil
 }
 
+// recoverUnfinishedJobs scans the job store for jobs still pending/running
+// from before the last restart. By default they're marked failed, since
+// their in-process goroutine and context are gone; with
+// Jobs.ResumeOnStart set, a job whose plan is still resumable via the
+// Plandex API is re-enqueued instead.
+func (s *APIServer) recoverUnfinishedJobs() {
+	records, err := s.jobManager.store.ListByStatus(JobStatusPending, JobStatusRunning)
+	if err != nil {
+		log.Printf("failed to scan job store for unfinished jobs: %v", err)
+		return
+	}
+
+	for _, rec := range records {
+		if s.config.Jobs.ResumeOnStart && !rec.IsBatch && rec.PlanID != "" && planIsResumable(rec.PlanID) {
+			job := jobFromRecord(rec)
+			job.onStatusChange = s.emitJobWebhook
+			s.jobManager.AddJob(job)
+
+			log.Printf("resuming job %s on plan %s after restart", job.ID, job.PlanID)
+			go s.executeJobAsync(job, job.Prompt, job.IsChatOnly, job.AutoContext, job.AutoApply)
+			continue
+		}
+
+		job := jobFromRecord(rec)
+		job.onStatusChange = s.emitJobWebhook
+		s.jobManager.AddJob(job)
+		job.SetError("server restarted")
+		job.SetStatus(JobStatusFailed)
+		log.Printf("marked job %s failed after restart (was %s)", job.ID, rec.Status)
+	}
+}
+
+// planIsResumable reports whether planID still exists per the Plandex API.
+// A plan that's been deleted out from under a pending job can't be
+// resumed, so such jobs fall back to being marked failed.
+func planIsResumable(planID string) bool {
+	plan, apiErr := api.Client.GetPlan(planID)
+	return apiErr == nil && plan != nil
+}
+
+// jobFromRecord rebuilds an in-memory Job (including its log buffer) from
+// a persisted JobRecord.
+func jobFromRecord(rec *JobRecord) *Job {
+	return &Job{
+		ID:          rec.ID,
+		Status:      rec.Status,
+		CreatedAt:   rec.CreatedAt,
+		StartedAt:   rec.StartedAt,
+		CompletedAt: rec.CompletedAt,
+		Result:      rec.Result,
+		Error:       rec.Error,
+		Prompt:      rec.Prompt,
+		IsChatOnly:  rec.IsChatOnly,
+		AutoContext: rec.AutoContext,
+		AutoApply:   rec.AutoApply,
+		PlanID:      rec.PlanID,
+		Branch:      rec.Branch,
+		Requester:   rec.Requester,
+		IsBatch:     rec.IsBatch,
+		logs:        append([]LogEntry(nil), rec.Logs...),
+		logOffset:   logOffsetFromEntries(rec.Logs),
+	}
+}
+
+func logOffsetFromEntries(entries []LogEntry) int {
+	if len(entries) == 0 {
+		return 0
+	}
+	last := entries[len(entries)-1]
+	return last.Offset + len(last.Data)
+}
+
 // requireFullCLISetup verifies that CLI is fully configured
 func (s *APIServer) requireFullCLISetup() error {
 	log.Printf("Debug: Starting CLI setup verification...")
@@ -266,6 +756,9 @@ func (s *APIServer) requireFullCLISetup() error {
 
 func (s *APIServer) setupRoutes() {
 	// Middleware
+	if s.config.Server.TLS.ClientAuth.Mode != "" && s.config.Server.TLS.ClientAuth.Mode != "none" {
+		s.router.Use(s.clientCertMiddleware)
+	}
 	if s.config.Auth.RequireAuth {
 		s.router.Use(s.authMiddleware)
 	}
@@ -282,6 +775,10 @@ func (s *APIServer) setupRoutes() {
 	// Tell endpoint
 	s.router.HandleFunc("/api/v1/tell", s.handleTell).Methods("POST")
 
+	// Batch chat/tell endpoints
+	s.router.HandleFunc("/api/v1/chat/batch", s.handleChatBatch).Methods("POST")
+	s.router.HandleFunc("/api/v1/tell/batch", s.handleTellBatch).Methods("POST")
+
 	// Plans management
 	s.router.HandleFunc("/api/v1/plans", s.handleListPlans).Methods("GET")
 	s.router.HandleFunc("/api/v1/plans/current", s.handleCurrentPlan).Methods("GET")
@@ -290,6 +787,13 @@ func (s *APIServer) setupRoutes() {
 	s.router.HandleFunc("/api/v1/jobs", s.handleListJobs).Methods("GET")
 	s.router.HandleFunc("/api/v1/jobs/{id}", s.handleGetJob).Methods("GET")
 	s.router.HandleFunc("/api/v1/jobs/{id}/cancel", s.handleCancelJob).Methods("POST")
+	s.router.HandleFunc("/api/v1/jobs/{id}/logs", s.handleStreamJobLogs).Methods("GET")
+
+	// Webhooks management
+	s.router.HandleFunc("/api/v1/webhooks", s.handleCreateWebhook).Methods("POST")
+	s.router.HandleFunc("/api/v1/webhooks", s.handleListWebhooks).Methods("GET")
+	s.router.HandleFunc("/api/v1/webhooks/{id}", s.handleDeleteWebhook).Methods("DELETE")
+	s.router.HandleFunc("/api/v1/webhooks/{id}/deliveries", s.handleListWebhookDeliveries).Methods("GET")
 
 	log.Println("âœ… Routes configured")
 }
@@ -310,7 +814,56 @@ func (s *APIServer) start() error {
 	// Graceful shutdown
 	go s.handleShutdown()
 
-	return s.server.ListenAndServe()
+	go s.runJobSweeper()
+
+	tlsCfg := s.config.Server.TLS
+	if !tlsCfg.Enabled && !tlsCfg.AutoCert.Enabled {
+		return s.server.ListenAndServe()
+	}
+
+	builtTLSConfig, autocertManager, err := buildTLSConfig(tlsCfg)
+	if err != nil {
+		log.Fatalf("failed to configure TLS: %v", err)
+	}
+	s.server.TLSConfig = builtTLSConfig
+	startHTTPSRedirector(autocertManager)
+
+	// CertFile/KeyFile (or AutoCert's GetCertificate) are already loaded
+	// into TLSConfig above, so they're omitted here.
+	return s.server.ListenAndServeTLS("", "")
+}
+
+// runJobSweeper periodically deletes terminal jobs older than
+// Jobs.DefaultTTL from both memory and the job store, per
+// Jobs.CleanupInterval. Malformed durations fall back to sane defaults so a
+// typo in config doesn't disable cleanup outright.
+func (s *APIServer) runJobSweeper() {
+	interval, err := time.ParseDuration(s.config.Jobs.CleanupInterval)
+	if err != nil || interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ttl, err := time.ParseDuration(s.config.Jobs.DefaultTTL)
+	if err != nil || ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-ttl)
+		ids, err := s.jobManager.store.DeleteOlderThan(cutoff, JobStatusCompleted, JobStatusFailed, JobStatusCancelled)
+		if err != nil {
+			log.Printf("job sweeper: failed to delete expired jobs from store: %v", err)
+			continue
+		}
+		for _, id := range ids {
+			s.jobManager.DeleteJob(id)
+		}
+		if len(ids) > 0 {
+			log.Printf("job sweeper: removed %d job(s) past TTL", len(ids))
+		}
+	}
 }
 
 func (s *APIServer) getCORSHandler() http.Handler {
@@ -336,6 +889,13 @@ func (s *APIServer) handleShutdown() {
 	defer cancel()
 
 	s.server.Shutdown(ctx)
+
+	if s.jobManager.store != nil {
+		if err := s.jobManager.store.Close(); err != nil {
+			log.Printf("failed to close job store: %v", err)
+		}
+	}
+
 	log.Println("Server stopped")
 }
 
@@ -347,9 +907,16 @@ func (s *APIServer) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// A verified, allow-listed mTLS client cert (stashed on the request
+		// context by clientCertMiddleware) is accepted in lieu of an API key.
+		if _, ok := peerIdentity(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		apiKey := r.Header.Get("X-API-Key")
 		if apiKey == "" {
-			s.writeError(w, http.StatusUnauthorized, "Missing API key")
+			s.writeError(w, http.StatusUnauthorized, "Missing API key or client certificate")
 			return
 		}
 
@@ -366,10 +933,21 @@ func (s *APIServer) authMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		r = r.WithContext(withPeerIdentity(r.Context(), apiKeyLabel(apiKey)))
 		next.ServeHTTP(w, r)
 	})
 }
 
+// apiKeyLabel derives a short, non-secret identity for an API key so it can
+// be attributed on jobs and webhook payloads without leaking the key
+// itself.
+func apiKeyLabel(apiKey string) string {
+	if len(apiKey) <= 8 {
+		return "api-key:***"
+	}
+	return "api-key:..." + apiKey[len(apiKey)-4:]
+}
+
 func (s *APIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	s.writeSuccess(w, map[string]string{
 		"status":      "healthy",
@@ -428,10 +1006,19 @@ func (s *APIServer) handleChat(w http.ResponseWriter, r *http.Request) {
 
 	// Create job
 	job := &Job{
-		ID:        generateJobID(),
-		Status:    JobStatusPending,
-		CreatedAt: time.Now(),
+		ID:          generateJobID(),
+		Status:      JobStatusPending,
+		CreatedAt:   time.Now(),
+		Prompt:      req.Prompt,
+		IsChatOnly:  true,
+		AutoContext: req.AutoContext,
+		PlanID:      lib.CurrentPlanId,
+		Branch:      lib.CurrentBranch,
+	}
+	if requester, ok := peerIdentity(r.Context()); ok {
+		job.Requester = requester
 	}
+	job.onStatusChange = s.emitJobWebhook
 
 	s.jobManager.AddJob(job)
 
@@ -459,10 +1046,19 @@ func (s *APIServer) handleTell(w http.ResponseWriter, r *http.Request) {
 
 	// Create job
 	job := &Job{
-		ID:        generateJobID(),
-		Status:    JobStatusPending,
-		CreatedAt: time.Now(),
+		ID:          generateJobID(),
+		Status:      JobStatusPending,
+		CreatedAt:   time.Now(),
+		Prompt:      req.Prompt,
+		AutoContext: req.AutoContext,
+		AutoApply:   req.AutoApply,
+		PlanID:      lib.CurrentPlanId,
+		Branch:      lib.CurrentBranch,
 	}
+	if requester, ok := peerIdentity(r.Context()); ok {
+		job.Requester = requester
+	}
+	job.onStatusChange = s.emitJobWebhook
 
 	s.jobManager.AddJob(job)
 
@@ -476,6 +1072,212 @@ func (s *APIServer) handleTell(w http.ResponseWriter, r *http.Request) {
 	}, "Tell job created successfully")
 }
 
+// BatchItem is one unit of work in a /chat/batch or /tell/batch request.
+type BatchItem struct {
+	ID          string `json:"id,omitempty"`
+	Prompt      string `json:"prompt"`
+	AutoContext bool   `json:"auto_context,omitempty"`
+	AutoApply   bool   `json:"auto_apply,omitempty"`
+}
+
+// BatchRequest is the body of POST /api/v1/chat/batch and
+// POST /api/v1/tell/batch. When FailFast is set, execution stops at the
+// first item failure instead of running the remaining items.
+type BatchRequest struct {
+	Items    []BatchItem `json:"items"`
+	FailFast bool        `json:"fail_fast,omitempty"`
+}
+
+// ItemOk is a successfully completed item in a BatchJobResult.
+type ItemOk struct {
+	Index  int                    `json:"index"`
+	ID     string                 `json:"id,omitempty"`
+	Result map[string]interface{} `json:"result,omitempty"`
+}
+
+// ItemFailure reports why one item in a batch failed, instead of the whole
+// job collapsing to a single opaque error string. Stage identifies which
+// part of execution failed ("validate", "tell_plan", "execute", or
+// "cancelled").
+type ItemFailure struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+	Stage  string `json:"stage"`
+}
+
+// BatchJobResult is the Result of a batch tell/chat Job: every item reports
+// its own outcome, so a client can tell exactly which items succeeded and
+// why the rest didn't without the job itself failing.
+type BatchJobResult struct {
+	Completed int           `json:"completed"`
+	Total     int           `json:"total"`
+	Ok        []ItemOk      `json:"ok,omitempty"`
+	Failed    []ItemFailure `json:"failed,omitempty"`
+}
+
+// batchProgressEvent is appended to the job's "progress" log stream after
+// each item finishes, so SSE/WebSocket subscribers can render per-item
+// pass/fail without waiting for the whole batch to complete.
+type batchProgressEvent struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id,omitempty"`
+	Ok        bool   `json:"ok"`
+	Reason    string `json:"reason,omitempty"`
+	Stage     string `json:"stage,omitempty"`
+	Completed int    `json:"completed"`
+	Total     int    `json:"total"`
+}
+
+func (s *APIServer) handleChatBatch(w http.ResponseWriter, r *http.Request) {
+	s.handleBatch(w, r, true)
+}
+
+func (s *APIServer) handleTellBatch(w http.ResponseWriter, r *http.Request) {
+	s.handleBatch(w, r, false)
+}
+
+func (s *APIServer) handleBatch(w http.ResponseWriter, r *http.Request, isChatOnly bool) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(req.Items) == 0 {
+		s.writeError(w, http.StatusBadRequest, "items cannot be empty")
+		return
+	}
+
+	job := &Job{
+		ID:         generateJobID(),
+		Status:     JobStatusPending,
+		CreatedAt:  time.Now(),
+		IsChatOnly: isChatOnly,
+		IsBatch:    true,
+		PlanID:     lib.CurrentPlanId,
+		Branch:     lib.CurrentBranch,
+	}
+	if requester, ok := peerIdentity(r.Context()); ok {
+		job.Requester = requester
+	}
+	job.onStatusChange = s.emitJobWebhook
+
+	s.jobManager.AddJob(job)
+
+	// Execute the batch asynchronously
+	go s.executeBatchAsync(job, req.Items, isChatOnly, req.FailFast)
+
+	s.writeSuccess(w, JobResponse{
+		JobID:     job.ID,
+		Status:    job.Status,
+		CreatedAt: job.CreatedAt,
+	}, "Batch job created successfully")
+}
+
+// executeBatchAsync runs items through executePlandexFunction one at a
+// time, capturing each item's panic or plan_exec.TellPlan error without
+// aborting the rest unless failFast. Progress is reported both via the
+// job's "progress" log stream, after each item, and via the final
+// BatchJobResult set as the job's Result.
+func (s *APIServer) executeBatchAsync(job *Job, items []BatchItem, isChatOnly, failFast bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	job.cancel = cancel
+
+	go func() {
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				job.SetError(fmt.Sprintf("batch job panicked: %v", r))
+				job.SetStatus(JobStatusFailed)
+			}
+		}()
+
+		job.SetStatus(JobStatusRunning)
+
+		batchResult := &BatchJobResult{Total: len(items)}
+
+		for i, item := range items {
+			itemResult, failure := s.executeBatchItem(ctx, job, i, item, isChatOnly)
+			batchResult.Completed++
+
+			event := batchProgressEvent{
+				Index:     i,
+				ID:        item.ID,
+				Ok:        failure == nil,
+				Completed: batchResult.Completed,
+				Total:     batchResult.Total,
+			}
+
+			if failure != nil {
+				event.Reason = failure.Reason
+				event.Stage = failure.Stage
+				batchResult.Failed = append(batchResult.Failed, *failure)
+			} else {
+				batchResult.Ok = append(batchResult.Ok, ItemOk{Index: i, ID: item.ID, Result: itemResult})
+			}
+
+			if data, err := json.Marshal(event); err == nil {
+				job.appendLog("progress", append(data, '\n'))
+			}
+
+			if failure != nil && failFast {
+				break
+			}
+		}
+
+		resultMap, err := structToResultMap(batchResult)
+		if err != nil {
+			job.SetError(fmt.Sprintf("failed to encode batch result: %v", err))
+			job.SetStatus(JobStatusFailed)
+			return
+		}
+
+		job.SetResult(resultMap)
+		job.SetStatus(JobStatusCompleted)
+	}()
+}
+
+// executeBatchItem runs a single batch item through executePlandexFunction,
+// converting a panic or TellPlan error into an ItemFailure instead of
+// letting either abort the rest of the batch.
+func (s *APIServer) executeBatchItem(ctx context.Context, job *Job, index int, item BatchItem, isChatOnly bool) (result map[string]interface{}, failure *ItemFailure) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = nil
+			failure = &ItemFailure{Index: index, ID: item.ID, Reason: fmt.Sprintf("%v", r), Stage: "execute"}
+		}
+	}()
+
+	if ctx.Err() != nil {
+		return nil, &ItemFailure{Index: index, ID: item.ID, Reason: ctx.Err().Error(), Stage: "cancelled"}
+	}
+
+	if strings.TrimSpace(item.Prompt) == "" {
+		return nil, &ItemFailure{Index: index, ID: item.ID, Reason: "prompt cannot be empty", Stage: "validate"}
+	}
+
+	res, err := s.executePlandexFunction(ctx, job, item.Prompt, isChatOnly, item.AutoContext, item.AutoApply)
+	if err != nil {
+		return nil, &ItemFailure{Index: index, ID: item.ID, Reason: err.Error(), Stage: "tell_plan"}
+	}
+	return res, nil
+}
+
+// structToResultMap round-trips v through JSON to produce the
+// map[string]interface{} shape Job.Result and JobRecord.Result expect.
+func structToResultMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal result: %w", err)
+	}
+	return m, nil
+}
+
 // executeJobAsync runs the Plandex command directly using plan_exec.TellPlan
 func (s *APIServer) executeJobAsync(job *Job, prompt string, isChatOnly, autoContext, autoApply bool) {
 	// Create context for cancellation
@@ -495,7 +1297,7 @@ func (s *APIServer) executeJobAsync(job *Job, prompt string, isChatOnly, autoCon
 		job.SetStatus(JobStatusRunning)
 
 		// Call plan_exec.TellPlan directly instead of using subprocess
-		result, err := s.executePlandexFunction(ctx, prompt, isChatOnly, autoContext, autoApply)
+		result, err := s.executePlandexFunction(ctx, job, prompt, isChatOnly, autoContext, autoApply)
 
 		if err != nil {
 			job.SetError(fmt.Sprintf("Plandex execution failed: %v", err))
@@ -509,17 +1311,17 @@ func (s *APIServer) executeJobAsync(job *Job, prompt string, isChatOnly, autoCon
 }
 
 // executePlandexFunction calls plan_exec.TellPlan directly
-func (s *APIServer) executePlandexFunction(ctx context.Context, prompt string, isChatOnly, autoContext, autoApply bool) (map[string]interface{}, error) {
+func (s *APIServer) executePlandexFunction(ctx context.Context, job *Job, prompt string, isChatOnly, autoContext, autoApply bool) (map[string]interface{}, error) {
 	// Set environment variables to disable TTY/UI components
 	os.Setenv("PLANDEX_DISABLE_TUI", "1")
-	os.Setenv("PLANDEX_HEADLESS", "1") 
+	os.Setenv("PLANDEX_HEADLESS", "1")
 	os.Setenv("PLANDEX_NON_INTERACTIVE", "1")
 	os.Setenv("CI", "true")
 	os.Setenv("TERM", "dumb")
 	os.Setenv("NO_COLOR", "1")
-	
+
 	log.Printf("Debug: Starting direct function execution")
-	
+
 	// Prepare execution parameters
 	authVars := lib.MustVerifyAuthVarsSilent(auth.Current.IntegratedModelsMode)
 
@@ -544,6 +1346,14 @@ func (s *APIServer) executePlandexFunction(ctx context.Context, prompt string, i
 		TellBg:          true,        // Run in background mode to avoid streaming UI
 	}
 
+	// Tee TellPlan's output into the job's log buffer in place of the TUI
+	// sink TellBg disables, so GET /jobs/{id}/logs has something to stream.
+	// This writer is per-job: unlike redirecting the process-wide
+	// os.Stdout/os.Stderr, it doesn't serialize concurrent jobs against
+	// each other or risk racing with unrelated code that writes to those
+	// package vars directly.
+	params.Output = &jobLogWriter{job: job, stream: "stdout"}
+
 	log.Printf("Debug: Calling TellPlan directly with flags: %+v", flags)
 
 	// Call TellPlan directly
@@ -565,6 +1375,21 @@ func (s *APIServer) executePlandexFunction(ctx context.Context, prompt string, i
 	return result, nil
 }
 
+// jobLogWriter is an io.Writer that tees everything written to it into a
+// job's log ring buffer under a fixed stream tag. executePlandexFunction
+// installs one as plan_exec.ExecParams.Output in place of the TUI sink
+// TellBg disables, so each job's output lands in its own buffer without
+// redirecting (and serializing callers of) the process-wide os.Stdout.
+type jobLogWriter struct {
+	job    *Job
+	stream string
+}
+
+func (w *jobLogWriter) Write(p []byte) (int, error) {
+	w.job.appendLog(w.stream, p)
+	return len(p), nil
+}
+
 func (s *APIServer) handleListPlans(w http.ResponseWriter, r *http.Request) {
 	plans, apiErr := api.Client.ListPlans([]string{lib.CurrentProjectId})
 	if apiErr != nil {
@@ -631,6 +1456,162 @@ func (s *APIServer) handleCancelJob(w http.ResponseWriter, r *http.Request) {
 	s.writeSuccess(w, map[string]interface{}{"job_id": jobID}, "Job cancelled")
 }
 
+// handleStreamJobLogs streams a job's captured stdout/stderr. It replays
+// buffered output since ?since=<offset> and, with ?follow=true, keeps the
+// connection open until the job reaches a terminal state or the client
+// disconnects. A request with an "Upgrade: websocket" header is served over
+// a WebSocket instead of Server-Sent Events.
+func (s *APIServer) handleStreamJobLogs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	job, exists := s.jobManager.GetJob(vars["id"])
+	if !exists {
+		s.writeError(w, http.StatusNotFound, "Job not found")
+		return
+	}
+
+	follow := r.URL.Query().Get("follow") == "true"
+	since := parseLogOffset(r.URL.Query().Get("since"))
+
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.streamJobLogsWS(w, r, job, follow, since)
+		return
+	}
+	s.streamJobLogsSSE(w, r, job, follow, since)
+}
+
+func parseLogOffset(raw string) int {
+	if raw == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+func (s *APIServer) streamJobLogsSSE(w http.ResponseWriter, r *http.Request, job *Job, follow bool, since int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	lastOffset := since
+	for _, entry := range job.LogsSince(since) {
+		writeSSELogEntry(w, entry)
+		lastOffset = entry.Offset + len(entry.Data)
+	}
+	flusher.Flush()
+
+	if !follow || job.IsComplete() {
+		writeSSEEnd(w, job)
+		flusher.Flush()
+		return
+	}
+
+	logCh, cancel := job.SubscribeLogs()
+	defer cancel()
+
+	// r.Context() is cancelled as soon as the client disconnects, filling
+	// the role http.CloseNotifier used to.
+	keepAlive := time.NewTicker(15 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case entry, ok := <-logCh:
+			if !ok {
+				// The job's run loop closes logCh right after applying its
+				// terminal update, so this always fires exactly once and
+				// only after any result/error the terminal status carried.
+				writeSSEEnd(w, job)
+				flusher.Flush()
+				return
+			}
+			if entry.Offset+len(entry.Data) <= lastOffset {
+				continue
+			}
+			writeSSELogEntry(w, entry)
+			lastOffset = entry.Offset + len(entry.Data)
+			flusher.Flush()
+
+			if job.IsComplete() {
+				writeSSEEnd(w, job)
+				flusher.Flush()
+				return
+			}
+		}
+	}
+}
+
+func writeSSELogEntry(w http.ResponseWriter, entry LogEntry) {
+	data, _ := json.Marshal(entry)
+	fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", entry.Offset, data)
+}
+
+func writeSSEEnd(w http.ResponseWriter, job *Job) {
+	data, _ := json.Marshal(map[string]interface{}{"status": job.GetStatus()})
+	fmt.Fprintf(w, "event: end\ndata: %s\n\n", data)
+}
+
+func (s *APIServer) streamJobLogsWS(w http.ResponseWriter, r *http.Request, job *Job, follow bool, since int) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	lastOffset := since
+	for _, entry := range job.LogsSince(since) {
+		if err := conn.WriteJSON(entry); err != nil {
+			return
+		}
+		lastOffset = entry.Offset + len(entry.Data)
+	}
+
+	if !follow || job.IsComplete() {
+		return
+	}
+
+	logCh, cancel := job.SubscribeLogs()
+	defer cancel()
+
+	ctx := r.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-logCh:
+			if !ok {
+				return
+			}
+			if entry.Offset+len(entry.Data) <= lastOffset {
+				continue
+			}
+			if err := conn.WriteJSON(entry); err != nil {
+				return
+			}
+			lastOffset = entry.Offset + len(entry.Data)
+
+			if job.IsComplete() {
+				return
+			}
+		}
+	}
+}
+
 // Response helpers
 type APIResponse struct {
 	Success bool        `json:"success"`
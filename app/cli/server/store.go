@@ -0,0 +1,480 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "modernc.org/sqlite"
+)
+
+// JobRecord is the persisted representation of a Job. It carries the
+// fields SetStatus/SetResult/SetError mutate plus everything needed to
+// replay or resume the job after a restart.
+type JobRecord struct {
+	ID          string
+	Status      JobStatus
+	CreatedAt   time.Time
+	StartedAt   *time.Time
+	CompletedAt *time.Time
+	Result      map[string]interface{}
+	Error       string
+	Prompt      string
+	IsChatOnly  bool
+	AutoContext bool
+	AutoApply   bool
+	PlanID      string
+	Branch      string
+	Requester   string
+	IsBatch     bool
+	Logs        []LogEntry
+}
+
+// JobStore persists JobRecords so jobs survive a server restart. The
+// default implementation is SQLite (modernc.org/sqlite, no cgo); Postgres
+// is available behind the same interface for deployments that already run
+// one.
+type JobStore interface {
+	// Save upserts a job record.
+	Save(rec *JobRecord) error
+	// ListByStatus returns every record currently in one of statuses.
+	ListByStatus(statuses ...JobStatus) ([]*JobRecord, error)
+	// Delete removes a record. It is not an error if id doesn't exist.
+	Delete(id string) error
+	// DeleteOlderThan removes every record in one of statuses whose
+	// CompletedAt precedes cutoff, returning the deleted IDs.
+	DeleteOlderThan(cutoff time.Time, statuses ...JobStatus) ([]string, error)
+	// Close releases the store's underlying connection.
+	Close() error
+
+	// SaveWebhookEndpoint upserts a webhook endpoint, persisted alongside
+	// jobs so registrations survive a restart.
+	SaveWebhookEndpoint(ep WebhookEndpoint) error
+	// DeleteWebhookEndpoint removes a webhook endpoint and its delivery
+	// history. It is not an error if id doesn't exist.
+	DeleteWebhookEndpoint(id string) error
+	// ListWebhookEndpoints returns every persisted webhook endpoint, in no
+	// particular order.
+	ListWebhookEndpoints() ([]WebhookEndpoint, error)
+	// SaveWebhookDeliveries replaces the persisted delivery history for an
+	// endpoint.
+	SaveWebhookDeliveries(endpointID string, deliveries []*WebhookDelivery) error
+	// ListWebhookDeliveries returns the persisted delivery history for every
+	// endpoint, keyed by endpoint ID.
+	ListWebhookDeliveries() (map[string][]*WebhookDelivery, error)
+}
+
+// NewJobStore opens the JobStore selected by cfg.StoreDriver ("sqlite", the
+// default, or "postgres"), creating its schema if needed.
+func NewJobStore(cfg JobsConfig) (JobStore, error) {
+	dsn := cfg.StoreDSN
+
+	switch cfg.StoreDriver {
+	case "", "sqlite":
+		if dsn == "" {
+			dsn = "plandex-jobs.db"
+		}
+		return newSQLJobStore("sqlite", dsn, sqliteSchema)
+	case "postgres":
+		if dsn == "" {
+			return nil, fmt.Errorf("jobs.store_dsn is required for the postgres job store")
+		}
+		return newSQLJobStore("postgres", dsn, postgresSchema)
+	default:
+		return nil, fmt.Errorf("unknown jobs.store_driver %q (want \"sqlite\" or \"postgres\")", cfg.StoreDriver)
+	}
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id            TEXT PRIMARY KEY,
+	status        TEXT NOT NULL,
+	created_at    DATETIME NOT NULL,
+	started_at    DATETIME,
+	completed_at  DATETIME,
+	result        TEXT,
+	error         TEXT,
+	prompt        TEXT,
+	is_chat_only  INTEGER NOT NULL DEFAULT 0,
+	auto_context  INTEGER NOT NULL DEFAULT 0,
+	auto_apply    INTEGER NOT NULL DEFAULT 0,
+	plan_id       TEXT,
+	branch        TEXT,
+	requester     TEXT,
+	is_batch      INTEGER NOT NULL DEFAULT 0,
+	logs          TEXT
+);
+CREATE INDEX IF NOT EXISTS jobs_status_idx ON jobs(status);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	events     TEXT,
+	secret     TEXT,
+	created_at DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	endpoint_id TEXT PRIMARY KEY,
+	deliveries  TEXT NOT NULL
+);
+`
+
+const postgresSchema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id            TEXT PRIMARY KEY,
+	status        TEXT NOT NULL,
+	created_at    TIMESTAMPTZ NOT NULL,
+	started_at    TIMESTAMPTZ,
+	completed_at  TIMESTAMPTZ,
+	result        TEXT,
+	error         TEXT,
+	prompt        TEXT,
+	is_chat_only  BOOLEAN NOT NULL DEFAULT FALSE,
+	auto_context  BOOLEAN NOT NULL DEFAULT FALSE,
+	auto_apply    BOOLEAN NOT NULL DEFAULT FALSE,
+	plan_id       TEXT,
+	branch        TEXT,
+	requester     TEXT,
+	is_batch      BOOLEAN NOT NULL DEFAULT FALSE,
+	logs          TEXT
+);
+CREATE INDEX IF NOT EXISTS jobs_status_idx ON jobs(status);
+
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	events     TEXT,
+	secret     TEXT,
+	created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	endpoint_id TEXT PRIMARY KEY,
+	deliveries  TEXT NOT NULL
+);
+`
+
+// sqlJobStore implements JobStore over database/sql. The same
+// implementation backs both drivers this package supports; sqlite and
+// Postgres agree closely enough on SQL (both use $-free "?" placeholders
+// once rebound per driver) that a single query set suffices.
+type sqlJobStore struct {
+	db     *sql.DB
+	driver string
+}
+
+func newSQLJobStore(driver, dsn, schema string) (*sqlJobStore, error) {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s job store at %q: %w", driver, dsn, err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s job store: %w", driver, err)
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s job store: %w", driver, err)
+	}
+	return &sqlJobStore{db: db, driver: driver}, nil
+}
+
+// bind rewrites "?" placeholders to "$1", "$2", ... for Postgres; sqlite
+// uses "?" natively.
+func (s *sqlJobStore) bind(query string) string {
+	if s.driver != "postgres" {
+		return query
+	}
+	out := make([]byte, 0, len(query)+8)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			out = append(out, fmt.Sprintf("$%d", n)...)
+		} else {
+			out = append(out, query[i])
+		}
+	}
+	return string(out)
+}
+
+func (s *sqlJobStore) Save(rec *JobRecord) error {
+	resultJSON, err := json.Marshal(rec.Result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	logsJSON, err := json.Marshal(rec.Logs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job logs: %w", err)
+	}
+
+	query := s.bind(`
+		INSERT INTO jobs (
+			id, status, created_at, started_at, completed_at, result, error,
+			prompt, is_chat_only, auto_context, auto_apply, plan_id, branch, requester, is_batch, logs
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			status = excluded.status,
+			started_at = excluded.started_at,
+			completed_at = excluded.completed_at,
+			result = excluded.result,
+			error = excluded.error,
+			logs = excluded.logs
+	`)
+
+	_, err = s.db.Exec(query,
+		rec.ID, string(rec.Status), rec.CreatedAt, rec.StartedAt, rec.CompletedAt,
+		string(resultJSON), rec.Error, rec.Prompt, rec.IsChatOnly, rec.AutoContext,
+		rec.AutoApply, rec.PlanID, rec.Branch, rec.Requester, rec.IsBatch, string(logsJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save job %s: %w", rec.ID, err)
+	}
+	return nil
+}
+
+func (s *sqlJobStore) ListByStatus(statuses ...JobStatus) ([]*JobRecord, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	placeholders := ""
+	args := make([]interface{}, len(statuses))
+	for i, st := range statuses {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += "?"
+		args[i] = string(st)
+	}
+
+	query := s.bind(fmt.Sprintf(`
+		SELECT id, status, created_at, started_at, completed_at, result, error,
+			prompt, is_chat_only, auto_context, auto_apply, plan_id, branch, requester, is_batch, logs
+		FROM jobs WHERE status IN (%s)
+	`, placeholders))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs by status: %w", err)
+	}
+	defer rows.Close()
+
+	var out []*JobRecord
+	for rows.Next() {
+		rec, err := scanJobRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rec)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlJobStore) Delete(id string) error {
+	_, err := s.db.Exec(s.bind(`DELETE FROM jobs WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *sqlJobStore) DeleteOlderThan(cutoff time.Time, statuses ...JobStatus) ([]string, error) {
+	if len(statuses) == 0 {
+		return nil, nil
+	}
+
+	placeholders := ""
+	args := make([]interface{}, 0, len(statuses)+1)
+	for i, st := range statuses {
+		if i > 0 {
+			placeholders += ", "
+		}
+		placeholders += "?"
+		args = append(args, string(st))
+	}
+	args = append(args, cutoff)
+
+	query := s.bind(fmt.Sprintf(`SELECT id FROM jobs WHERE status IN (%s) AND completed_at < ?`, placeholders))
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired jobs: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan expired job id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if err := s.Delete(id); err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+func (s *sqlJobStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *sqlJobStore) SaveWebhookEndpoint(ep WebhookEndpoint) error {
+	eventsJSON, err := json.Marshal(ep.Events)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook endpoint events: %w", err)
+	}
+
+	query := s.bind(`
+		INSERT INTO webhook_endpoints (id, url, events, secret, created_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			url = excluded.url,
+			events = excluded.events,
+			secret = excluded.secret
+	`)
+	if _, err := s.db.Exec(query, ep.ID, ep.URL, string(eventsJSON), ep.Secret, ep.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save webhook endpoint %s: %w", ep.ID, err)
+	}
+	return nil
+}
+
+func (s *sqlJobStore) DeleteWebhookEndpoint(id string) error {
+	if _, err := s.db.Exec(s.bind(`DELETE FROM webhook_endpoints WHERE id = ?`), id); err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint %s: %w", id, err)
+	}
+	if _, err := s.db.Exec(s.bind(`DELETE FROM webhook_deliveries WHERE endpoint_id = ?`), id); err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries for %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *sqlJobStore) ListWebhookEndpoints() ([]WebhookEndpoint, error) {
+	rows, err := s.db.Query(`SELECT id, url, events, secret, created_at FROM webhook_endpoints`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var out []WebhookEndpoint
+	for rows.Next() {
+		var (
+			ep         WebhookEndpoint
+			eventsJSON string
+		)
+		if err := rows.Scan(&ep.ID, &ep.URL, &eventsJSON, &ep.Secret, &ep.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		if eventsJSON != "" {
+			if err := json.Unmarshal([]byte(eventsJSON), &ep.Events); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal webhook endpoint events: %w", err)
+			}
+		}
+		out = append(out, ep)
+	}
+	return out, rows.Err()
+}
+
+func (s *sqlJobStore) SaveWebhookDeliveries(endpointID string, deliveries []*WebhookDelivery) error {
+	data, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook deliveries: %w", err)
+	}
+
+	query := s.bind(`
+		INSERT INTO webhook_deliveries (endpoint_id, deliveries)
+		VALUES (?, ?)
+		ON CONFLICT (endpoint_id) DO UPDATE SET deliveries = excluded.deliveries
+	`)
+	if _, err := s.db.Exec(query, endpointID, string(data)); err != nil {
+		return fmt.Errorf("failed to save webhook deliveries for %s: %w", endpointID, err)
+	}
+	return nil
+}
+
+func (s *sqlJobStore) ListWebhookDeliveries() (map[string][]*WebhookDelivery, error) {
+	rows, err := s.db.Query(`SELECT endpoint_id, deliveries FROM webhook_deliveries`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[string][]*WebhookDelivery)
+	for rows.Next() {
+		var endpointID, data string
+		if err := rows.Scan(&endpointID, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+		}
+		var dels []*WebhookDelivery
+		if err := json.Unmarshal([]byte(data), &dels); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal webhook deliveries for %s: %w", endpointID, err)
+		}
+		out[endpointID] = dels
+	}
+	return out, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJobRecord(row rowScanner) (*JobRecord, error) {
+	var (
+		rec                                JobRecord
+		status, result, logs               string
+		startedAt, completedAt             sql.NullTime
+		isChatOnly, autoContext, autoApply bool
+		prompt, errStr, planID, branch     string
+		requester                          string
+		isBatch                            bool
+	)
+
+	if err := row.Scan(
+		&rec.ID, &status, &rec.CreatedAt, &startedAt, &completedAt, &result, &errStr,
+		&prompt, &isChatOnly, &autoContext, &autoApply, &planID, &branch, &requester, &isBatch, &logs,
+	); err != nil {
+		return nil, fmt.Errorf("failed to scan job record: %w", err)
+	}
+
+	rec.Status = JobStatus(status)
+	rec.Error = errStr
+	rec.Prompt = prompt
+	rec.IsChatOnly = isChatOnly
+	rec.AutoContext = autoContext
+	rec.AutoApply = autoApply
+	rec.PlanID = planID
+	rec.Branch = branch
+	rec.Requester = requester
+	rec.IsBatch = isBatch
+
+	if startedAt.Valid {
+		t := startedAt.Time
+		rec.StartedAt = &t
+	}
+	if completedAt.Valid {
+		t := completedAt.Time
+		rec.CompletedAt = &t
+	}
+	if result != "" {
+		if err := json.Unmarshal([]byte(result), &rec.Result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job result: %w", err)
+		}
+	}
+	if logs != "" {
+		if err := json.Unmarshal([]byte(logs), &rec.Logs); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal job logs: %w", err)
+		}
+	}
+
+	return &rec, nil
+}
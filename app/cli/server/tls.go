@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// peerIdentityKey is the context key authMiddleware and job creation
+// handlers read the authenticated requester's identity from, however it was
+// established (API key, JWT subject, or verified mTLS client cert CN/SAN).
+type peerIdentityKey struct{}
+
+// peerIdentity returns the authenticated requester identity stashed on ctx,
+// if any.
+func peerIdentity(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(peerIdentityKey{}).(string)
+	return id, ok
+}
+
+// withPeerIdentity returns a copy of ctx carrying id as the authenticated
+// requester identity.
+func withPeerIdentity(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, peerIdentityKey{}, id)
+}
+
+// buildTLSConfig turns a TLSConfig into a *tls.Config plus, when AutoCert is
+// enabled, the autocert.Manager backing it (the caller needs it to also
+// serve ACME's http-01 challenge on the :80 redirector; nil otherwise).
+// CertFile/KeyFile are ignored when AutoCert is enabled. When
+// ClientAuth.CAFile is set, client certs are verified for mTLS, required or
+// merely requested per ClientAuth.Mode.
+func buildTLSConfig(cfg TLSConfig) (*tls.Config, *autocert.Manager, error) {
+	tlsCfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	var m *autocert.Manager
+	if cfg.AutoCert.Enabled {
+		if len(cfg.AutoCert.Domains) == 0 {
+			return nil, nil, fmt.Errorf("tls.auto_cert.domains is required when tls.auto_cert.enabled is set")
+		}
+		m = &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutoCert.Domains...),
+			Cache:      autocert.DirCache(cfg.AutoCert.CacheDir),
+			Email:      cfg.AutoCert.Email,
+		}
+		tlsCfg.GetCertificate = m.GetCertificate
+	} else {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, nil, fmt.Errorf("tls.cert_file and tls.key_file are required unless tls.auto_cert.enabled is set")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.ClientAuth.Mode != "" && cfg.ClientAuth.Mode != "none" {
+		if cfg.ClientAuth.CAFile == "" {
+			return nil, nil, fmt.Errorf("tls.client_auth.ca_file is required when tls.client_auth.mode is %q", cfg.ClientAuth.Mode)
+		}
+		caCert, err := os.ReadFile(cfg.ClientAuth.CAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, nil, fmt.Errorf("failed to parse client CA file: %s", cfg.ClientAuth.CAFile)
+		}
+		tlsCfg.ClientCAs = pool
+
+		switch cfg.ClientAuth.Mode {
+		case "require":
+			tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+		case "request":
+			tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+		default:
+			return nil, nil, fmt.Errorf("unknown tls.client_auth.mode %q (want \"none\", \"request\", or \"require\")", cfg.ClientAuth.Mode)
+		}
+	}
+
+	return tlsCfg, m, nil
+}
+
+// verifiedClientIdentity returns the CommonName of the request's verified
+// client certificate and whether it's authorized per cfg's CN/SAN
+// allow-lists (empty allow-lists mean "any cert signed by CAFile is
+// authorized"). ok is false if no client cert was presented.
+func verifiedClientIdentity(cfg ClientAuthConfig, state *tls.ConnectionState) (string, bool) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return "", false
+	}
+	cert := state.PeerCertificates[0]
+
+	if len(cfg.AllowedCNs) == 0 && len(cfg.AllowedSANs) == 0 {
+		return cert.Subject.CommonName, true
+	}
+
+	for _, cn := range cfg.AllowedCNs {
+		if cert.Subject.CommonName == cn {
+			return cert.Subject.CommonName, true
+		}
+	}
+	for _, san := range cfg.AllowedSANs {
+		for _, name := range cert.DNSNames {
+			if name == san {
+				return cert.Subject.CommonName, true
+			}
+		}
+		for _, email := range cert.EmailAddresses {
+			if email == san {
+				return cert.Subject.CommonName, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// clientCertMiddleware stashes the request's verified client certificate
+// identity, if any and if authorized per TLS.ClientAuth's allow-lists, onto
+// the request context ahead of authMiddleware.
+func (s *APIServer) clientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			if cn, ok := verifiedClientIdentity(s.config.Server.TLS.ClientAuth, r.TLS); ok {
+				r = r.WithContext(withPeerIdentity(r.Context(), cn))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpsRedirectHandler answers every request by redirecting to the same URL
+// over https, except ACME's http-01 challenge path which autocert's Manager
+// handles directly.
+func httpsRedirectHandler(m *autocert.Manager) http.Handler {
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := "https://" + strings.Split(r.Host, ":")[0] + r.URL.RequestURI()
+		http.Redirect(w, r, target, http.StatusMovedPermanently)
+	})
+	if m == nil {
+		return redirect
+	}
+	return m.HTTPHandler(redirect)
+}
+
+// startHTTPSRedirector runs an HTTP server on :80 that redirects to https,
+// serving ACME's http-01 challenge along the way when m is non-nil. Errors
+// are logged, not fatal, since the main HTTPS listener is what matters.
+func startHTTPSRedirector(m *autocert.Manager) {
+	go func() {
+		if err := http.ListenAndServe(":80", httpsRedirectHandler(m)); err != nil {
+			log.Printf("HTTPS redirector on :80 stopped: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,454 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// WebhookEndpoint is a user-registered webhook subscription. If Events is
+// empty, the endpoint receives every job lifecycle event.
+type WebhookEndpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events,omitempty"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookEnvelope is the JSON body POSTed to registered endpoints on every
+// job lifecycle transition.
+type WebhookEnvelope struct {
+	Event       string                 `json:"event"`
+	JobID       string                 `json:"job_id"`
+	Status      string                 `json:"status"`
+	CreatedAt   time.Time              `json:"created_at"`
+	StartedAt   *time.Time             `json:"started_at,omitempty"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+	Result      map[string]interface{} `json:"result,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	Requester   string                 `json:"requester,omitempty"`
+}
+
+// WebhookDelivery records the outcome of delivering an event to an
+// endpoint, including retries.
+type WebhookDelivery struct {
+	ID           string     `json:"id"`
+	EndpointID   string     `json:"endpoint_id"`
+	Event        string     `json:"event"`
+	JobID        string     `json:"job_id"`
+	Attempts     int        `json:"attempts"`
+	StatusCode   int        `json:"status_code,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Delivered    bool       `json:"delivered"`
+	DeadLettered bool       `json:"dead_lettered"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+}
+
+// webhookTask is a delivery queued on the dispatcher's work channel.
+type webhookTask struct {
+	endpoint WebhookEndpoint
+	envelope WebhookEnvelope
+	delivery *WebhookDelivery
+	attempt  int
+}
+
+// maxWebhookDeliveriesPerEndpoint bounds the delivery history retained in
+// memory (and persisted) for a single endpoint. Without a cap, a
+// long-lived endpoint's history grows forever and so does the payload GET
+// /api/v1/webhooks/{id}/deliveries returns.
+const maxWebhookDeliveriesPerEndpoint = 200
+
+// WebhookDispatcher owns the set of registered webhook endpoints and fans
+// out job lifecycle events to them via a bounded worker pool, retrying
+// failed deliveries with exponential backoff up to Webhooks.MaxRetries.
+type WebhookDispatcher struct {
+	config  WebhooksConfig
+	backoff time.Duration
+	store   JobStore
+
+	mu         sync.RWMutex
+	endpoints  map[string]WebhookEndpoint
+	deliveries map[string][]*WebhookDelivery // keyed by endpoint ID
+
+	httpClient *http.Client
+	queue      chan webhookTask
+}
+
+// NewWebhookDispatcher creates a WebhookDispatcher, rehydrates it from
+// store, and starts its worker pool. Workers run until the process exits;
+// there is one dispatcher per APIServer. store may be nil, in which case
+// registrations and delivery history don't survive a restart (e.g. in
+// tests).
+func NewWebhookDispatcher(cfg WebhooksConfig, store JobStore) *WebhookDispatcher {
+	backoff, err := time.ParseDuration(cfg.RetryBackoff)
+	if err != nil || backoff <= 0 {
+		backoff = time.Second
+	}
+
+	d := &WebhookDispatcher{
+		config:     cfg,
+		backoff:    backoff,
+		store:      store,
+		endpoints:  make(map[string]WebhookEndpoint),
+		deliveries: make(map[string][]*WebhookDelivery),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		queue:      make(chan webhookTask, 256),
+	}
+	d.rehydrate()
+
+	workers := cfg.MaxRetries + 1
+	if workers < 4 {
+		workers = 4
+	}
+	for i := 0; i < workers; i++ {
+		go d.worker()
+	}
+
+	return d
+}
+
+// rehydrate restores registered endpoints and their delivery history from
+// store so both survive a restart.
+func (d *WebhookDispatcher) rehydrate() {
+	if d.store == nil {
+		return
+	}
+
+	endpoints, err := d.store.ListWebhookEndpoints()
+	if err != nil {
+		log.Printf("failed to load persisted webhook endpoints: %v", err)
+		return
+	}
+	for _, ep := range endpoints {
+		d.endpoints[ep.ID] = ep
+	}
+
+	deliveries, err := d.store.ListWebhookDeliveries()
+	if err != nil {
+		log.Printf("failed to load persisted webhook deliveries: %v", err)
+		return
+	}
+	for id, dels := range deliveries {
+		d.deliveries[id] = dels
+	}
+}
+
+// RegisterEndpoint adds a new webhook subscription. An empty secret falls
+// back to Webhooks.Secret.
+func (d *WebhookDispatcher) RegisterEndpoint(url string, events []string, secret string) (*WebhookEndpoint, error) {
+	if url == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if secret == "" {
+		secret = d.config.Secret
+	}
+
+	ep := WebhookEndpoint{
+		ID:        generateWebhookID(),
+		URL:       url,
+		Events:    events,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}
+
+	d.mu.Lock()
+	d.endpoints[ep.ID] = ep
+	d.mu.Unlock()
+
+	if d.store != nil {
+		if err := d.store.SaveWebhookEndpoint(ep); err != nil {
+			log.Printf("failed to persist webhook endpoint %s: %v", ep.ID, err)
+		}
+	}
+
+	return &ep, nil
+}
+
+// ListEndpoints returns every registered webhook subscription.
+func (d *WebhookDispatcher) ListEndpoints() []WebhookEndpoint {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	out := make([]WebhookEndpoint, 0, len(d.endpoints))
+	for _, ep := range d.endpoints {
+		out = append(out, ep)
+	}
+	return out
+}
+
+// RemoveEndpoint deletes a webhook subscription.
+func (d *WebhookDispatcher) RemoveEndpoint(id string) error {
+	d.mu.Lock()
+	if _, exists := d.endpoints[id]; !exists {
+		d.mu.Unlock()
+		return fmt.Errorf("webhook endpoint not found: %s", id)
+	}
+	delete(d.endpoints, id)
+	delete(d.deliveries, id)
+	d.mu.Unlock()
+
+	if d.store != nil {
+		if err := d.store.DeleteWebhookEndpoint(id); err != nil {
+			log.Printf("failed to delete persisted webhook endpoint %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// Deliveries returns deep copies of the delivery history for an endpoint,
+// oldest first. Copies are returned (rather than the stored pointers)
+// because workers mutate deliveries in place as attempts land.
+func (d *WebhookDispatcher) Deliveries(endpointID string) ([]*WebhookDelivery, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if _, exists := d.endpoints[endpointID]; !exists {
+		return nil, fmt.Errorf("webhook endpoint not found: %s", endpointID)
+	}
+
+	hist := d.deliveries[endpointID]
+	out := make([]*WebhookDelivery, len(hist))
+	for i, del := range hist {
+		cp := *del
+		out[i] = &cp
+	}
+	return out, nil
+}
+
+// Dispatch enqueues delivery of envelope to every endpoint subscribed to
+// envelope.Event. It never blocks the caller on network I/O.
+func (d *WebhookDispatcher) Dispatch(envelope WebhookEnvelope) {
+	if !d.config.Enabled {
+		return
+	}
+
+	d.mu.RLock()
+	var matched []WebhookEndpoint
+	for _, ep := range d.endpoints {
+		if endpointWantsEvent(ep, envelope.Event) {
+			matched = append(matched, ep)
+		}
+	}
+	d.mu.RUnlock()
+
+	for _, ep := range matched {
+		delivery := &WebhookDelivery{
+			ID:         generateDeliveryID(),
+			EndpointID: ep.ID,
+			Event:      envelope.Event,
+			JobID:      envelope.JobID,
+			CreatedAt:  time.Now(),
+		}
+
+		d.mu.Lock()
+		hist := append(d.deliveries[ep.ID], delivery)
+		if len(hist) > maxWebhookDeliveriesPerEndpoint {
+			hist = hist[len(hist)-maxWebhookDeliveriesPerEndpoint:]
+		}
+		d.deliveries[ep.ID] = hist
+		d.mu.Unlock()
+
+		select {
+		case d.queue <- webhookTask{endpoint: ep, envelope: envelope, delivery: delivery}:
+		default:
+			log.Printf("webhook queue full, dropping delivery %s to endpoint %s", delivery.ID, ep.ID)
+			d.mu.Lock()
+			delivery.LastError = "delivery queue full"
+			delivery.DeadLettered = true
+			d.mu.Unlock()
+		}
+
+		d.persistDeliveries(ep.ID)
+	}
+}
+
+// persistDeliveries snapshots the current delivery history for endpointID
+// under the lock and writes it to store. It's a no-op when store is nil.
+func (d *WebhookDispatcher) persistDeliveries(endpointID string) {
+	if d.store == nil {
+		return
+	}
+
+	d.mu.RLock()
+	hist := d.deliveries[endpointID]
+	snapshot := make([]*WebhookDelivery, len(hist))
+	for i, del := range hist {
+		cp := *del
+		snapshot[i] = &cp
+	}
+	d.mu.RUnlock()
+
+	if err := d.store.SaveWebhookDeliveries(endpointID, snapshot); err != nil {
+		log.Printf("failed to persist webhook deliveries for endpoint %s: %v", endpointID, err)
+	}
+}
+
+func endpointWantsEvent(ep WebhookEndpoint, event string) bool {
+	if len(ep.Events) == 0 {
+		return true
+	}
+	for _, e := range ep.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// worker drains the delivery queue, retrying failed sends with exponential
+// backoff until MaxRetries is exhausted.
+func (d *WebhookDispatcher) worker() {
+	for t := range d.queue {
+		d.attemptDelivery(t)
+	}
+}
+
+func (d *WebhookDispatcher) attemptDelivery(t webhookTask) {
+	for {
+		t.attempt++
+
+		statusCode, err := d.send(t.endpoint, t.envelope)
+		if err == nil {
+			now := time.Now()
+			d.mu.Lock()
+			t.delivery.Attempts = t.attempt
+			t.delivery.Delivered = true
+			t.delivery.StatusCode = statusCode
+			t.delivery.DeliveredAt = &now
+			d.mu.Unlock()
+			d.persistDeliveries(t.endpoint.ID)
+			return
+		}
+
+		d.mu.Lock()
+		t.delivery.Attempts = t.attempt
+		t.delivery.StatusCode = statusCode
+		t.delivery.LastError = err.Error()
+		d.mu.Unlock()
+		log.Printf("webhook delivery attempt %d failed for endpoint %s: %v", t.attempt, t.endpoint.ID, err)
+
+		if t.attempt > d.config.MaxRetries {
+			d.mu.Lock()
+			t.delivery.DeadLettered = true
+			d.mu.Unlock()
+			log.Printf("webhook delivery dead-lettered for endpoint %s after %d attempts", t.endpoint.ID, t.attempt)
+			d.persistDeliveries(t.endpoint.ID)
+			return
+		}
+
+		time.Sleep(d.backoff * time.Duration(1<<uint(t.attempt-1)))
+	}
+}
+
+// send POSTs the envelope to the endpoint, signing the body with
+// HMAC-SHA256 when a webhook secret is configured.
+func (d *WebhookDispatcher) send(ep WebhookEndpoint, envelope WebhookEnvelope) (int, error) {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", ep.URL, bytes.NewBuffer(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "plandex-cli/1.0")
+
+	if ep.Secret != "" {
+		req.Header.Set("X-Plandex-Signature", signWebhookPayload(payload, ep.Secret))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook endpoint returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload computes the HMAC-SHA256 signature sent in the
+// X-Plandex-Signature header, following the same "sha256=<hex>" scheme
+// GitHub uses so consumers can reuse existing verifiers.
+func signWebhookPayload(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return fmt.Sprintf("sha256=%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
+func generateWebhookID() string {
+	return fmt.Sprintf("wh_%d", time.Now().UnixNano())
+}
+
+func generateDeliveryID() string {
+	return fmt.Sprintf("whd_%d", time.Now().UnixNano())
+}
+
+// createWebhookRequest is the body of POST /api/v1/webhooks.
+type createWebhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+func (s *APIServer) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req createWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	ep, err := s.webhookDispatcher.RegisterEndpoint(req.URL, req.Events, req.Secret)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.writeSuccess(w, ep, "Webhook registered successfully")
+}
+
+func (s *APIServer) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	s.writeSuccess(w, s.webhookDispatcher.ListEndpoints(), "Webhooks retrieved successfully")
+}
+
+func (s *APIServer) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	if err := s.webhookDispatcher.RemoveEndpoint(id); err != nil {
+		s.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	s.writeSuccess(w, map[string]interface{}{"id": id}, "Webhook removed")
+}
+
+func (s *APIServer) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	deliveries, err := s.webhookDispatcher.Deliveries(id)
+	if err != nil {
+		s.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	s.writeSuccess(w, deliveries, "Deliveries retrieved successfully")
+}